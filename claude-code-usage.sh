@@ -16,27 +16,6 @@ log() {
 
 log "Starting Claude Code usage capture..."
 
-# Check if jq is installed, install if missing
-if ! command -v jq &> /dev/null; then
-    log "jq not found, attempting to install..."
-
-    # Check if brew is available
-    if command -v brew &> /dev/null; then
-        log "Installing jq with Homebrew..."
-        if brew install jq >> "$EXEC_LOG" 2>&1; then
-            log "jq installed successfully"
-        else
-            log "ERROR: Failed to install jq with Homebrew"
-            exit 1
-        fi
-    else
-        log "ERROR: jq is required but not installed, and Homebrew is not available."
-        log "Install Homebrew first: /bin/bash -c \"\$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)\""
-        log "Or install jq manually: brew install jq"
-        exit 1
-    fi
-fi
-
 # Check if expect is installed, install if missing
 if ! command -v expect &> /dev/null; then
     log "expect not found, attempting to install..."
@@ -70,36 +49,8 @@ fi
 SCRIPT_DIR=$(cd "$(dirname "$0")" && pwd)
 log "Script running from: $SCRIPT_DIR"
 
-# Backup .claude.json
-cp "$CLAUDE_CONFIG" "$CLAUDE_CONFIG.bak"
-log "Backed up .claude.json to .claude.json.bak"
-
-# Add current directory to trusted projects
-log "Adding $SCRIPT_DIR to trusted directories..."
-jq --arg dir "$SCRIPT_DIR" '
-  .projects[$dir] = {
-    "allowedTools": [],
-    "mcpContextUris": [],
-    "mcpServers": {},
-    "enabledMcpjsonServers": [],
-    "disabledMcpjsonServers": [],
-    "hasTrustDialogAccepted": true,
-    "projectOnboardingSeenCount": 0,
-    "hasClaudeMdExternalIncludesApproved": false,
-    "hasClaudeMdExternalIncludesWarningShown": false,
-    "exampleFiles": []
-  }' "$CLAUDE_CONFIG" > "$CLAUDE_CONFIG.tmp"
-
-# Validate the temporary file is valid JSON
-if ! jq empty "$CLAUDE_CONFIG.tmp" 2>/dev/null; then
-    log "ERROR: Failed to update .claude.json - invalid JSON generated"
-    rm "$CLAUDE_CONFIG.tmp"
-    exit 1
-fi
-
-# Move temp file to original
-mv "$CLAUDE_CONFIG.tmp" "$CLAUDE_CONFIG"
-log "Successfully added $SCRIPT_DIR to trusted directories"
+# The monitor trusts this directory in ~/.claude.json before running the
+# script, through the same ledger its cleanup-trust command undoes
 
 # Find claude CLI in common locations
 CLAUDE_CMD=""
@@ -190,10 +141,13 @@ if grep -q "Current session" "$OUTPUT_DIR/claude-code-usage.log"; then
 
     TIMESTAMP=$(date -u +"%Y-%m-%dT%H:%M:%SZ")
 
-    # Create JSON file with both Opus and Sonnet fields for backwards compatibility
+    # Create JSON file with both Opus and Sonnet fields for backwards compatibility.
+    # It's written to a temp file and renamed so readers never see it half-written.
     log "Creating JSON output..."
-    cat > "$OUTPUT_DIR/claude-code-usage.json" <<EOF
+    JSON_TMP="$OUTPUT_DIR/.claude-code-usage.json.$$"
+    cat > "$JSON_TMP" <<EOF
 {
+  "schema_version": 1,
   "session_percent": ${SESSION_PERCENT:-0},
   "session_reset": "${SESSION_RESET}",
   "week_all_percent": ${WEEK_ALL_PERCENT:-0},
@@ -205,6 +159,7 @@ if grep -q "Current session" "$OUTPUT_DIR/claude-code-usage.log"; then
   "timestamp": "${TIMESTAMP}"
 }
 EOF
+    mv -f "$JSON_TMP" "$OUTPUT_DIR/claude-code-usage.json"
 
     log "JSON file created: $OUTPUT_DIR/claude-code-usage.json"
 
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/collector"
+	"github.com/ribeirogab/claude-code-monitor/internal/config"
+	"github.com/ribeirogab/claude-code-monitor/internal/trust"
+)
+
+// command is a CLI subcommand, run instead of the menu bar app
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{"cleanup-trust", "Remove the directory trust entries the monitor added to claude's config", runCleanupTrust},
+	{"find-claude", "Show where the claude CLI is looked for and which one is used", runFindClaude},
+	{"hook", "Record a Claude Code hook event (JSON on stdin), see README for the settings", runHook},
+	{"replay", "Parse archived raw captures again and report those that now parse differently", runReplay},
+	{"tokens", "Show token usage per model and 5-hour window, read from claude's transcripts", runTokens},
+	{"cost", "Estimate what usage would have cost on the API, per day, week, model and session", runCost},
+	{"projects", "Show usage per project (and git branch) over a time range", runProjects},
+	{"windows", "Show each usage window of a limit: peak, time above 80% and at 100%", runWindows},
+	{"export", "Write the usage history as CSV, JSON or NDJSON", runExport},
+}
+
+// runCommand runs the subcommand named by args[0] and returns the exit code
+func runCommand(args []string) int {
+	name := args[0]
+
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(args[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+			return 0
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+	printUsage()
+	return 2
+}
+
+func printUsage() {
+	fmt.Println("Usage: claude-code-monitor [command]")
+	fmt.Println()
+	fmt.Println("Without a command, the menu bar app is started.")
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-16s %s\n", cmd.name, cmd.description)
+	}
+}
+
+// monitorDir returns ~/.claude-code-monitor
+func monitorDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".claude-code-monitor"), nil
+}
+
+// findProfile returns the configured profile with the given label (the
+// default one when empty) and its data directory
+func findProfile(label string) (config.Profile, string, error) {
+	dir, err := monitorDir()
+	if err != nil {
+		return config.Profile{}, "", err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return config.Profile{}, "", err
+	}
+
+	profiles := cfg.ActiveProfiles()
+	if label == "" {
+		return profiles[0], profiles[0].Dir(dir), nil
+	}
+	for _, p := range profiles {
+		if p.Is(label) {
+			return p, p.Dir(dir), nil
+		}
+	}
+	return config.Profile{}, "", fmt.Errorf("no profile labeled %q in config.json", label)
+}
+
+func runFindClaude(args []string) error {
+	fs := flag.NewFlagSet("find-claude", flag.ContinueOnError)
+	profileLabel := fs.String("profile", "", "profile label (default: first profile)")
+	all := fs.Bool("all", false, "also list locations that don't exist")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	profile, _, err := findProfile(*profileLabel)
+	if err != nil {
+		return err
+	}
+
+	if profile.Transport != "" {
+		fmt.Printf("claude runs through %q, it's looked up in the PATH on the other side\n", profile.Transport)
+		return nil
+	}
+
+	d := collector.Discover(profile.ClaudePath)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tSOURCE\tPATH\tRESULT")
+	skipped := 0
+	for _, c := range d.Candidates {
+		mark, result := " ", c.Reason
+		if c.Path == d.Path {
+			mark, result = "*", "used"
+		} else if c.Reason == "does not exist" && !*all {
+			skipped++
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, c.Source, c.Path, result)
+	}
+	w.Flush()
+
+	if skipped > 0 {
+		fmt.Printf("\n%d more locations don't exist, use -all to list them\n", skipped)
+	}
+	if d.Path == "" {
+		return &collector.NotFoundError{Candidates: d.Candidates}
+	}
+	if version, err := collector.ClaudeVersion(context.Background(), d.Path, profile.ClaudeConfigDir); err == nil {
+		fmt.Printf("\nUsing %s (version %s)\n", d.Path, version)
+	} else {
+		fmt.Printf("\nUsing %s (%v)\n", d.Path, err)
+	}
+	return nil
+}
+
+func runCleanupTrust(args []string) error {
+	dir, err := monitorDir()
+	if err != nil {
+		return err
+	}
+
+	// Older versions trusted the output directory itself
+	removed, err := trust.Cleanup(filepath.Join(dir, "trusted-dirs.json"), []string{dir})
+	for _, r := range removed {
+		if r.Legacy {
+			fmt.Printf("Removed %s from %s (added by an older version)\n", r.Dir, r.ConfigPath)
+		} else {
+			fmt.Printf("Removed %s from %s\n", r.Dir, r.ConfigPath)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(removed) == 0 {
+		fmt.Println("No trust entries to remove")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/pricing"
+	"github.com/ribeirogab/claude-code-monitor/internal/transcript"
+)
+
+// topSessions is how many sessions the cost command lists
+const topSessions = 10
+
+// loadPricing reads the pricing table, with the overrides from the monitor's
+// config directory
+func loadPricing() (*pricing.Table, error) {
+	dir, err := monitorDir()
+	if err != nil {
+		return pricing.Default(), err
+	}
+	return pricing.Load(filepath.Join(dir, pricing.FileName))
+}
+
+// formatCost formats a cost estimate in USD
+func formatCost(e *pricing.Estimate) string {
+	if e == nil {
+		return "$0.00"
+	}
+	return fmt.Sprintf("$%.2f", e.Cost)
+}
+
+func runCost(args []string) error {
+	fs := flag.NewFlagSet("cost", flag.ContinueOnError)
+	profileLabel := fs.String("profile", "", "profile label (default: first profile)")
+	days := fs.Int("days", 30, "number of days to include")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	profile, dir, err := findProfile(*profileLabel)
+	if err != nil {
+		return err
+	}
+	ix, err := openTranscripts(profile, dir)
+	if err != nil {
+		return err
+	}
+	table, err := loadPricing()
+	if err != nil {
+		return err
+	}
+
+	from := transcript.StartOfDay(time.Now().AddDate(0, 0, -*days+1))
+	buckets := ix.Buckets(from, time.Time{})
+	total := table.Total(buckets)
+
+	fmt.Printf("Estimated API cost since %s: %s\n", from.Format("Jan 2"), formatCost(&total))
+	if len(total.Unpriced) > 0 {
+		fmt.Printf("No price for %s, add them to %s\n", strings.Join(total.Unpriced, ", "), pricing.FileName)
+	}
+
+	printEstimates("DAY", table.Estimate(buckets, transcript.ByDay), sortedKeys)
+	printEstimates("WEEK OF", table.Estimate(buckets, transcript.ByWeek), sortedKeys)
+	printEstimates("MODEL", table.Estimate(buckets, transcript.ByModel), byCost)
+	printEstimates("SESSION", table.Estimate(buckets, transcript.BySession), func(m map[string]*pricing.Estimate) []string {
+		keys := byCost(m)
+		if len(keys) > topSessions {
+			keys = keys[:topSessions]
+		}
+		return keys
+	})
+
+	return nil
+}
+
+// printEstimates prints a table of estimates in the order given by keys
+func printEstimates(title string, estimates map[string]*pricing.Estimate, keys func(map[string]*pricing.Estimate) []string) {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tTOKENS\tCOST\n", title)
+	for _, k := range keys(estimates) {
+		e := estimates[k]
+		fmt.Fprintf(w, "%s\t%d\t%s\n", k, e.Tokens.Total(), formatCost(e))
+	}
+	w.Flush()
+}
+
+// byCost returns the keys of m, most expensive first
+func byCost(m map[string]*pricing.Estimate) []string {
+	keys := sortedKeys(m)
+	sort.SliceStable(keys, func(i, j int) bool { return m[keys[i]].Cost > m[keys[j]].Cost })
+	return keys
+}
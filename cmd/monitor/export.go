@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/history"
+)
+
+// Export formats
+const (
+	formatCSV    = "csv"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+// pointWriter writes history points in an export format
+type pointWriter interface {
+	write(p history.Point) error
+	close() error
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	profileLabel := fs.String("profile", "", "profile label (default: first profile)")
+	format := fs.String("format", formatCSV, "output format: csv, json or ndjson")
+	metric := fs.String("metric", "", "comma separated limit ids to export, e.g. session (default: all)")
+	days := fs.Int("days", 0, "number of days to include, when -from isn't set (default: all history)")
+	from := fs.String("from", "", "first day to include (YYYY-MM-DD)")
+	to := fs.String("to", "", "last day to include (YYYY-MM-DD)")
+	output := fs.String("o", "", "file to write to (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format != formatCSV && *format != formatJSON && *format != formatNDJSON {
+		return fmt.Errorf("unknown format %q, expected csv, json or ndjson", *format)
+	}
+	start, end, err := parseRange(*from, *to, *days)
+	if err != nil {
+		return err
+	}
+	if *from == "" && *days <= 0 {
+		start = time.Time{}
+	}
+	_, dir, err := findProfile(*profileLabel)
+	if err != nil {
+		return err
+	}
+
+	query := history.Query{From: start, To: end}
+	for _, m := range strings.Split(*metric, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			query.Metrics = append(query.Metrics, m)
+		}
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, err)
+		}
+		defer out.Close()
+	}
+	buf := bufio.NewWriter(out)
+
+	w, err := newPointWriter(*format, buf)
+	if err != nil {
+		return err
+	}
+
+	store := history.New(filepath.Join(dir, history.DirName))
+	if err := store.Each(query, w.write); err != nil {
+		return err
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if *output != "" {
+		// The deferred Close ignores errors, this one reports them
+		return out.Close()
+	}
+	return nil
+}
+
+// newPointWriter returns the writer of an export format
+func newPointWriter(format string, out io.Writer) (pointWriter, error) {
+	switch format {
+	case formatJSON:
+		return newJSONWriter(out)
+	case formatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(out)}, nil
+	}
+	return newCSVWriter(out)
+}
+
+// csvWriter writes one row per point, with a header
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(out io.Writer) (*csvWriter, error) {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"time", "metric", "percent", "resets_at"}); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: w}, nil
+}
+
+func (c *csvWriter) write(p history.Point) error {
+	resetsAt := ""
+	if p.ResetsAt != nil {
+		resetsAt = p.ResetsAt.Format(time.RFC3339)
+	}
+	return c.w.Write([]string{p.Time.Format(time.RFC3339), p.Metric, strconv.Itoa(p.Percent), resetsAt})
+}
+
+func (c *csvWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonWriter writes a single JSON array, one point at a time
+type jsonWriter struct {
+	out   io.Writer
+	count int
+}
+
+func newJSONWriter(out io.Writer) (*jsonWriter, error) {
+	if _, err := io.WriteString(out, "["); err != nil {
+		return nil, err
+	}
+	return &jsonWriter{out: out}, nil
+}
+
+func (j *jsonWriter) write(p history.Point) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if j.count == 0 {
+		sep = "\n  "
+	}
+	j.count++
+	if _, err := io.WriteString(j.out, sep); err != nil {
+		return err
+	}
+	_, err = j.out.Write(data)
+	return err
+}
+
+func (j *jsonWriter) close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "]\n"
+	}
+	_, err := io.WriteString(j.out, end)
+	return err
+}
+
+// ndjsonWriter writes one JSON object per line
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) write(p history.Point) error {
+	return n.enc.Encode(p)
+}
+
+func (n *ndjsonWriter) close() error {
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/getlantern/systray"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/sessions"
+)
+
+const (
+	// hookEventsFile stores the events recorded by the hook command
+	hookEventsFile = "hook-events.jsonl"
+
+	// activeSessionSlots is how many sessions the "Active sessions" submenu lists
+	activeSessionSlots = 8
+
+	// sessionsRefreshInterval is how often the sessions submenu is refreshed
+	sessionsRefreshInterval = 30 * time.Second
+)
+
+var (
+	mSessions    *systray.MenuItem
+	sessionSlots []*systray.MenuItem
+	sessionStore *sessions.Store
+)
+
+// runHook records a Claude Code hook event. It must stay quiet on stdout,
+// since claude adds the output of some hooks to the conversation.
+func runHook(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: claude-code-monitor hook <event>, with the hook JSON on stdin")
+	}
+
+	dir, err := monitorDir()
+	if err != nil {
+		return err
+	}
+
+	event, err := sessions.ParseHookInput(args[0], os.Stdin)
+	if err != nil {
+		return err
+	}
+	if isMonitorRun(event, dir) {
+		return nil
+	}
+	return sessions.NewStore(filepath.Join(dir, hookEventsFile)).Append(event)
+}
+
+// isMonitorRun reports whether an event comes from the claude the monitor
+// itself starts to read /usage, which isn't a session of the user's
+func isMonitorRun(e sessions.Event, outputDir string) bool {
+	if e.CWD == "" {
+		return false
+	}
+	workspace := filepath.Join(outputDir, workspaceDir)
+	rel, err := filepath.Rel(workspace, filepath.Clean(e.CWD))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// createSessionsMenu adds the "Active sessions" submenu, hidden until the
+// hook command records a session
+func createSessionsMenu(outputDir string) {
+	sessionStore = sessions.NewStore(filepath.Join(outputDir, hookEventsFile))
+
+	mSessions = systray.AddMenuItem("Active sessions", "Recorded by the claude-code-monitor hook command")
+	for i := 0; i < activeSessionSlots; i++ {
+		item := mSessions.AddSubMenuItem("", "")
+		item.Disable()
+		sessionSlots = append(sessionSlots, item)
+	}
+	systray.AddSeparator()
+
+	updateSessionsMenu()
+}
+
+// watchSessions refreshes the sessions submenu until ctx is done. Reading
+// the hook events is cheap, unlike a collection run.
+func watchSessions(ctx context.Context) {
+	ticker := time.NewTicker(sessionsRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			updateSessionsMenu()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func updateSessionsMenu() {
+	now := time.Now()
+	// Every kept event, so a long session's start and tool count are complete
+	events, err := sessionStore.Events(time.Time{})
+	if err != nil {
+		log.Printf("Failed to read hook events: %v", err)
+		return
+	}
+
+	// Events recorded before the hook learned to skip them
+	userEvents := events[:0]
+	for _, e := range events {
+		if !isMonitorRun(e, outputDir) {
+			userEvents = append(userEvents, e)
+		}
+	}
+
+	var active []*sessions.Session
+	for _, s := range sessions.Sessions(userEvents) {
+		if s.Active(now) {
+			active = append(active, s)
+		}
+	}
+
+	if len(active) == 0 {
+		mSessions.Hide()
+		return
+	}
+
+	mSessions.SetTitle(fmt.Sprintf("Active sessions (%d)", len(active)))
+	for i, item := range sessionSlots {
+		if i >= len(active) {
+			item.Hide()
+			continue
+		}
+		item.SetTitle(formatSession(active[i], now))
+		item.SetTooltip(active[i].ID)
+		item.Show()
+	}
+	mSessions.Show()
+}
+
+// formatSession describes a session in one line, e.g.
+// "● ~/src/app · claude-opus-4-5 · 12 tools · 3m ago"
+func formatSession(s *sessions.Session, now time.Time) string {
+	state := "○"
+	if s.Working() {
+		state = "●"
+	}
+
+	text := state + " " + shortenPath(s.CWD)
+	if s.Model != "" {
+		text += " · " + s.Model
+	}
+	text += fmt.Sprintf(" · %d tools · %s", s.ToolUses, formatAgo(now.Sub(s.LastActivity)))
+	return text
+}
+
+// formatAgo formats a duration in the past compactly, e.g. "3m ago"
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh ago", int(d.Hours()))
+}
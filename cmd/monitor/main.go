@@ -1,20 +1,32 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/getlantern/systray"
 
+	"github.com/ribeirogab/claude-code-monitor/internal/archive"
+	"github.com/ribeirogab/claude-code-monitor/internal/breaker"
+	"github.com/ribeirogab/claude-code-monitor/internal/collector"
 	"github.com/ribeirogab/claude-code-monitor/internal/config"
+	"github.com/ribeirogab/claude-code-monitor/internal/coordinator"
 	"github.com/ribeirogab/claude-code-monitor/internal/executor"
+	"github.com/ribeirogab/claude-code-monitor/internal/forecast"
+	"github.com/ribeirogab/claude-code-monitor/internal/history"
+	"github.com/ribeirogab/claude-code-monitor/internal/pricing"
 	"github.com/ribeirogab/claude-code-monitor/internal/scheduler"
+	"github.com/ribeirogab/claude-code-monitor/internal/status"
+	"github.com/ribeirogab/claude-code-monitor/internal/transcript"
 	"github.com/ribeirogab/claude-code-monitor/internal/updater"
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
 )
 
 // AppVersion is set at build time via ldflags
@@ -25,46 +37,70 @@ const (
 	GitHubRepo  = "claude-code-monitor"
 )
 
-type UsageData struct {
-	SessionPercent    int    `json:"session_percent"`
-	SessionReset      string `json:"session_reset"`
-	WeekAllPercent    int    `json:"week_all_percent"`
-	WeekAllReset      string `json:"week_all_reset"`
-	WeekOpusPercent   int    `json:"week_opus_percent"`
-	WeekOpusReset     string `json:"week_opus_reset"`
-	WeekSonnetPercent int    `json:"week_sonnet_percent"`
-	WeekSonnetReset   string `json:"week_sonnet_reset"`
-	Timestamp         string `json:"timestamp"`
-}
-
-type MenuItemRefs struct {
-	sessionPercent    *systray.MenuItem
-	sessionReset      *systray.MenuItem
-	weekAllPercent    *systray.MenuItem
-	weekAllReset      *systray.MenuItem
-	weekOpusPercent   *systray.MenuItem
-	weekOpusReset     *systray.MenuItem
-	weekSonnetPercent *systray.MenuItem
-	weekSonnetReset   *systray.MenuItem
-	lastUpdate        *systray.MenuItem
+// workspaceDir is where the pty collector runs claude, inside the output directory
+const workspaceDir = "workspace"
+
+// topProjectSlots is how many projects the "Top projects this week" submenu lists
+const topProjectSlots = 5
+
+// spareLimitSlots is how many hidden menu slots are created for limits that
+// show up after startup, since systray can't insert items later
+const spareLimitSlots = 3
+
+type LimitMenuItem struct {
+	percent  *systray.MenuItem
+	reset    *systray.MenuItem
+	forecast *systray.MenuItem // where the current pace leads, hidden without one
+	pace     *systray.MenuItem // weekly limits against an even pace
+}
+
+// ProfileMenu is the menu section of a single profile
+type ProfileMenu struct {
+	header       *systray.MenuItem // only shown with several profiles
+	errorLine    *systray.MenuItem // hidden while the last run succeeded
+	errorHint    *systray.MenuItem
+	circuit      *systray.MenuItem // shown while backing off after failures
+	warning      *systray.MenuItem // shown when the screen may have been misread
+	limits       []*LimitMenuItem
+	cost         *systray.MenuItem // API cost estimate from the transcripts
+	projects     *systray.MenuItem // "Top projects this week" submenu
+	projectSlots []*systray.MenuItem
+	lastUpdate   *systray.MenuItem
+}
+
+// ProfileState ties a monitored profile to its data files and menu section
+type ProfileState struct {
+	profile       config.Profile
+	dir           string
+	executor      *executor.Executor
+	menu          *ProfileMenu
+	transcripts   *transcript.Index // opened on first use
+	transcriptsMu sync.Mutex
 }
 
 var (
 	sched             *scheduler.Scheduler
-	menuRefs          *MenuItemRefs
-	usageDataPath     string
+	profileStates     []*ProfileState
 	appConfig         *config.Config
+	configErr         error // why config.json couldn't be loaded, it's not saved over then
 	mUpdateNow        *systray.MenuItem
 	intervalMenuItems map[int]*systray.MenuItem
 	mDisabled         *systray.MenuItem
 	outputDir         string
-	scriptPath        string
 	taskWithUpdate    func() error
+	runCoordinator    *coordinator.Coordinator
+	appCtx            context.Context
+	cancelRuns        context.CancelFunc
 	appUpdater        *updater.Updater
 	mUpdateAvailable  *systray.MenuItem
 )
 
 func main() {
+	// Subcommands run without the menu bar
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	// Setup logging
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -115,19 +151,35 @@ func onReady() {
 		log.Fatalf("Failed to get home directory: %v", err)
 	}
 
-	outputDir := filepath.Join(homeDir, ".claude-code-monitor")
-	usageDataPath = filepath.Join(outputDir, "claude-code-usage.json")
+	outputDir = filepath.Join(homeDir, ".claude-code-monitor")
 
 	// Load configuration
-	appConfig, err = config.LoadConfig()
-	if err != nil {
-		log.Printf("Failed to load config, using defaults: %v", err)
+	appConfig, configErr = config.LoadConfig()
+	if configErr != nil {
+		log.Printf("Failed to load config, using defaults: %v", configErr)
 		appConfig = config.DefaultConfig()
 	}
 	log.Printf("Config loaded: auto-update=%v", appConfig.AutoUpdateEnabled)
 
+	for _, profile := range appConfig.ActiveProfiles() {
+		profileStates = append(profileStates, &ProfileState{
+			profile: profile,
+			dir:     profile.Dir(outputDir),
+		})
+	}
+	log.Printf("Monitoring %d profile(s)", len(profileStates))
+
+	// The settings in use aren't the user's, say so rather than hide it
+	if configErr != nil {
+		mConfigError := systray.AddMenuItem("⚠️ Invalid config.json, using defaults", configErr.Error())
+		mConfigError.Disable()
+		systray.AddSeparator()
+	}
+
 	// Create menu items with usage data
 	createMenuItems()
+	createSessionsMenu(outputDir)
+	go refreshTranscripts()
 	log.Println("Usage menu items created")
 
 	// Add control menu items
@@ -208,20 +260,53 @@ func onReady() {
 	mQuit := systray.AddMenuItem("Quit", "")
 	log.Println("Quit menu item added")
 
-	scriptPath = findScriptPath()
-	log.Printf("Script path: %s", scriptPath)
 	log.Printf("Output directory: %s", outputDir)
 
-	exec := executor.New(scriptPath, outputDir)
+	for _, state := range profileStates {
+		usageCollector, err := newCollector(appConfig.Collector, state.profile, outputDir)
+		if err != nil {
+			log.Printf("Failed to set up %q collector, using pty: %v", appConfig.Collector.Type, err)
+			usageCollector = collector.NewPTY(ptyConfig(appConfig.Collector, state.profile, outputDir))
+		} else {
+			log.Printf("Using %q collector for profile %s", appConfig.Collector.Type, profileName(state.profile))
+		}
+		state.executor = executor.New(usageCollector, state.dir, executor.Config{
+			Timeout: appConfig.Collector.Timeout(),
+			Retries: appConfig.Collector.Retries,
+			Breaker: breaker.Config{Threshold: appConfig.Collector.BreakerThreshold},
+		})
+	}
+
+	// Cancelled on exit so a running collection doesn't outlive the app
+	appCtx, cancelRuns = context.WithCancel(context.Background())
+	go watchSessions(appCtx)
+
+	// Single run shared by every trigger, updating the menu afterwards
+	runCoordinator = coordinator.New(appCtx, func(ctx context.Context) error {
+		var errs []error
+		for _, state := range profileStates {
+			if err := state.executor.Execute(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("profile %s: %w", profileName(state.profile), err))
+			}
+		}
+		updateMenuItems()
+		log.Println("Menu items updated")
+		return errors.Join(errs...)
+	}, appConfig.Collector.MinGap())
+
+	// Reflect runs from any trigger in the "Update Now" item
+	runCoordinator.OnStateChange = func(inFlight bool) {
+		if inFlight {
+			mUpdateNow.SetTitle("Updating...")
+			mUpdateNow.Disable()
+		} else {
+			mUpdateNow.Enable()
+			mUpdateNow.SetTitle("Update Now")
+		}
+	}
 
-	// Wrapper to update menu after execution
 	taskWithUpdate = func() error {
-		err := exec.Execute()
-		if err == nil {
-			updateMenuItems()
-			log.Println("Menu items updated")
-		}
-		return err
+		return runCoordinator.Run(appCtx)
 	}
 
 	// Create scheduler with configured interval
@@ -244,15 +329,16 @@ func onReady() {
 	go func() {
 		for range mUpdateNow.ClickedCh {
 			log.Println("Manual update triggered")
-			mUpdateNow.SetTitle("Updating...")
-			mUpdateNow.Disable()
 
-			if err := taskWithUpdate(); err != nil {
-				log.Printf("Manual update failed: %v", err)
+			// Asking for an update overrides the backoff
+			for _, state := range profileStates {
+				state.executor.Breaker().Probe()
 			}
 
-			mUpdateNow.Enable()
-			mUpdateNow.SetTitle("Update Now")
+			// The min gap only spaces out automatic runs
+			if err := runCoordinator.RunNow(appCtx); err != nil {
+				log.Printf("Manual update failed: %v", err)
+			}
 		}
 	}()
 
@@ -269,9 +355,7 @@ func onReady() {
 
 			// Update config
 			appConfig.AutoUpdateEnabled = false
-			if err := config.SaveConfig(appConfig); err != nil {
-				log.Printf("Failed to save config: %v", err)
-			}
+			saveConfig()
 
 			// Pause scheduler
 			sched.Pause()
@@ -301,9 +385,7 @@ func onReady() {
 				// Update config
 				appConfig.AutoUpdateEnabled = true
 				appConfig.UpdateInterval = seconds
-				if err := config.SaveConfig(appConfig); err != nil {
-					log.Printf("Failed to save config: %v", err)
-				}
+				saveConfig()
 
 				// Restart scheduler with new interval
 				sched.Stop()
@@ -329,13 +411,73 @@ func onReady() {
 
 func onExit() {
 	log.Println("onExit() called")
+	if cancelRuns != nil {
+		cancelRuns()
+	}
 	if sched != nil {
 		sched.Stop()
 	}
 	log.Println("Application exited")
 }
 
-func findScriptPath() string {
+// profileName returns the label shown for a profile in logs
+func profileName(profile config.Profile) string {
+	if profile.Label == "" {
+		return "default"
+	}
+	return profile.Label
+}
+
+// newCollector creates the collector selected in the config for a profile
+func newCollector(cfg config.CollectorConfig, profile config.Profile, outputDir string) (collector.Collector, error) {
+	switch cfg.Type {
+	case config.CollectorPTY, "":
+		return collector.NewPTY(ptyConfig(cfg, profile, outputDir)), nil
+	case config.CollectorScript:
+		scriptPath := cfg.ScriptPath
+		if scriptPath == "" {
+			path, err := findScriptPath()
+			if err != nil {
+				return nil, err
+			}
+			scriptPath = path
+		}
+		return collector.NewScript(scriptPath, outputDir,
+			filepath.Join(outputDir, "trusted-dirs.json"), cfg.TrustMode == config.TrustTransient), nil
+	case config.CollectorFixture:
+		if cfg.FixturePath == "" {
+			return nil, fmt.Errorf("fixture collector requires fixture_path")
+		}
+		return collector.NewFixture(cfg.FixturePath), nil
+	case config.CollectorRemote:
+		if cfg.Source == "" {
+			return nil, fmt.Errorf("remote collector requires source")
+		}
+		return collector.NewRemote(cfg.Source), nil
+	default:
+		return nil, fmt.Errorf("unknown collector type %q", cfg.Type)
+	}
+}
+
+// ptyConfig builds the PTY collector settings for a profile, running claude
+// from a dedicated workspace inside the output directory
+func ptyConfig(cfg config.CollectorConfig, profile config.Profile, outputDir string) collector.PTYConfig {
+	pc := collector.PTYConfig{
+		ClaudePath:     profile.ClaudePath,
+		WorkDir:        filepath.Join(outputDir, workspaceDir),
+		ConfigDir:      profile.ClaudeConfigDir,
+		TransientTrust: cfg.TrustMode == config.TrustTransient,
+		LedgerPath:     filepath.Join(outputDir, "trusted-dirs.json"),
+		LogPath:        filepath.Join(profile.Dir(outputDir), "claude-code-usage.log"),
+		Transport:      config.SplitCommand(profile.Transport),
+	}
+	if cfg.ArchiveKeep > 0 {
+		pc.Archive = archive.New(filepath.Join(profile.Dir(outputDir), captureArchiveDir), cfg.ArchiveKeep)
+	}
+	return pc
+}
+
+func findScriptPath() (string, error) {
 	// Try to find the script in common locations
 	locations := []string{
 		"./claude-code-usage.sh",
@@ -358,12 +500,11 @@ func findScriptPath() string {
 			continue
 		}
 		if _, err := os.Stat(absPath); err == nil {
-			return absPath
+			return absPath, nil
 		}
 	}
 
-	log.Fatal("Could not find claude-code-usage.sh script")
-	return ""
+	return "", fmt.Errorf("could not find claude-code-usage.sh script")
 }
 
 func loadIconByName(iconName string) ([]byte, error) {
@@ -418,26 +559,85 @@ func updateIcon(sessionPercent int) {
 	log.Printf("Icon updated to: %s (session: %d%%)", iconName, sessionPercent)
 }
 
-func loadUsageData() (*UsageData, error) {
-	data, err := os.ReadFile(usageDataPath)
+func (p *ProfileState) loadUsageData() (*usage.UsageData, error) {
+	return usage.Load(filepath.Join(p.dir, "claude-code-usage.json"))
+}
+
+// loadForecasts returns the burn rate forecast of each limit from the
+// profile's usage history
+func (p *ProfileState) loadForecasts(data *usage.UsageData) map[string]forecast.Forecast {
+	store := history.New(filepath.Join(p.dir, history.DirName))
+	points, err := store.Query(history.Query{From: time.Now().Add(-forecast.MaxWindow)})
 	if err != nil {
-		return nil, err
+		log.Printf("Failed to read usage history for profile %s: %v", profileName(p.profile), err)
+		return nil
 	}
 
-	var usage UsageData
-	if err := json.Unmarshal(data, &usage); err != nil {
-		return nil, err
+	forecasts := make(map[string]forecast.Forecast)
+	for _, limit := range data.Limits {
+		if f, ok := forecast.Burn(limit.ID, points); ok {
+			forecasts[limit.ID] = f
+		}
 	}
+	return forecasts
+}
 
-	return &usage, nil
+// transcriptUsage is what the menu shows from a profile's transcripts
+type transcriptUsage struct {
+	today     pricing.Estimate
+	week      pricing.Estimate
+	projects  []string // most used this week first
+	byProject map[string]*pricing.Estimate
 }
 
-func hasOpusAccess(usage *UsageData) bool {
-	return usage.WeekOpusReset != ""
+// loadTranscriptUsage reads the profile's new transcript lines and sums up
+// this week, or returns nil when there are no transcripts
+func (p *ProfileState) loadTranscriptUsage() *transcriptUsage {
+	// The startup refresh and a collection run may overlap
+	p.transcriptsMu.Lock()
+	defer p.transcriptsMu.Unlock()
+
+	if p.transcripts == nil {
+		ix, err := openTranscripts(p.profile, p.dir)
+		if err != nil {
+			log.Printf("Transcript usage unavailable for profile %s: %v", profileName(p.profile), err)
+			return nil
+		}
+		p.transcripts = ix
+	} else if err := p.transcripts.Update(); err != nil {
+		log.Printf("Failed to read transcripts for profile %s: %v", profileName(p.profile), err)
+	}
+
+	table, err := loadPricing()
+	if err != nil {
+		log.Printf("Failed to load pricing, using defaults: %v", err)
+	}
+
+	now := time.Now()
+	week := p.transcripts.Buckets(transcript.StartOfWeek(now), time.Time{})
+	u := &transcriptUsage{
+		today: table.Total(p.transcripts.Buckets(transcript.StartOfDay(now), time.Time{})),
+		week:  table.Total(week),
+	}
+	u.projects, u.byProject = topProjects(table, week, transcript.ByProject, topProjectSlots)
+	return u
+}
+
+// loadStatus returns the outcome of the profile's last run, nil if unknown
+func (p *ProfileState) loadStatus() *status.Status {
+	st, err := status.Load(filepath.Join(p.dir, status.FileName))
+	if err != nil {
+		return nil
+	}
+	return st
 }
 
-func hasSonnetAccess(usage *UsageData) bool {
-	return usage.WeekSonnetReset != ""
+// dataTime returns when usage data was collected, or now if unknown
+func dataTime(data *usage.UsageData) time.Time {
+	if t, err := time.Parse(time.RFC3339, data.Timestamp); err == nil {
+		return t
+	}
+	return time.Now()
 }
 
 func formatTimestamp(timestamp string) string {
@@ -466,124 +666,344 @@ func getUsageEmoji(percent int) string {
 	return "🔴"
 }
 
-func updateMenuItems() {
-	usage, err := loadUsageData()
-	if err != nil {
-		log.Printf("Failed to load usage data: %v", err)
-		return
+func formatLimit(limit usage.Limit) string {
+	return fmt.Sprintf("%-18s %02d%%   %s", limit.Label(), limit.Percent, getUsageEmoji(limit.Percent))
+}
+
+func formatReset(limit usage.Limit) string {
+	if limit.Reset == "" {
+		return "resets: N/A"
 	}
+	return fmt.Sprintf("resets %s", removeTimezone(limit.Reset))
+}
 
-	if menuRefs == nil {
+// formatForecast describes where the current pace of a limit leads, e.g.
+// "at this pace: limit at 4:10pm (resets 10pm)"
+func formatForecast(f forecast.Forecast) string {
+	if f.HitsLimit() {
+		if f.ResetsAt == nil {
+			return fmt.Sprintf("at this pace: limit at %s", formatClock(*f.LimitAt))
+		}
+		return fmt.Sprintf("at this pace: limit at %s (resets %s)", formatClock(*f.LimitAt), formatClock(*f.ResetsAt))
+	}
+	if atReset, ok := f.AtReset(); ok {
+		return fmt.Sprintf("at this pace: %.0f%% at reset", atReset)
+	}
+	return fmt.Sprintf("at this pace: +%.1f%%/h", f.RatePerHour)
+}
+
+// formatClock formats a time of day, with the weekday when it isn't today
+func formatClock(t time.Time) string {
+	local, now := t.Local(), time.Now()
+	if local.Year() == now.Year() && local.YearDay() == now.YearDay() {
+		return local.Format("3:04pm")
+	}
+	return local.Format("Mon 3:04pm")
+}
+
+func (m *LimitMenuItem) show(limit usage.Limit, f forecast.Forecast, hasForecast bool) {
+	m.percent.SetTitle(formatLimit(limit))
+	m.reset.SetTitle(formatReset(limit))
+	m.percent.Show()
+	m.reset.Show()
+	m.showForecast(f, hasForecast)
+}
+
+// showForecast shows the forecast of a growing limit, and hides it otherwise
+func (m *LimitMenuItem) showForecast(f forecast.Forecast, ok bool) {
+	if !ok || f.RatePerHour <= 0 {
+		m.forecast.Hide()
 		return
 	}
+	m.forecast.SetTitle(formatForecast(f))
+	m.forecast.SetTooltip(fmt.Sprintf("+%.1f%%/h over the last %s", f.RatePerHour, formatWindow(forecast.Window(f.Metric))))
+	m.forecast.Show()
+}
 
-	// Update icon based on session usage
-	updateIcon(usage.SessionPercent)
+// showPace compares a weekly limit at time at with an even pace, and hides
+// the line for other limits
+func (m *LimitMenuItem) showPace(limit usage.Limit, at time.Time) {
+	pace, ok := forecast.PaceOf(limit, at)
+	if !ok {
+		m.pace.Hide()
+		return
+	}
+	m.pace.SetTitle(formatPace(pace))
+	m.pace.SetTooltip(fmt.Sprintf("%.0f%% used, %.0f%% of the week gone by", pace.Used, pace.Elapsed))
+	m.pace.Show()
+}
 
-	// Update Session
-	if menuRefs.sessionPercent != nil {
-		menuRefs.sessionPercent.SetTitle(fmt.Sprintf("Session              %02d%%   %s", usage.SessionPercent, getUsageEmoji(usage.SessionPercent)))
+// formatPace describes a pace, e.g. "12% ahead of pace"
+func formatPace(p forecast.Pace) string {
+	ahead := p.Ahead()
+	switch {
+	case ahead >= 1:
+		return fmt.Sprintf("%.0f%% ahead of pace", ahead)
+	case ahead <= -1:
+		return fmt.Sprintf("%.0f%% under pace", -ahead)
 	}
-	if menuRefs.sessionReset != nil {
-		menuRefs.sessionReset.SetTitle(fmt.Sprintf("resets %s", removeTimezone(usage.SessionReset)))
+	return "on pace"
+}
+
+// formatWindow formats a burn rate window, e.g. "2 hours"
+func formatWindow(d time.Duration) string {
+	if hours := int(d.Hours()); hours != 1 {
+		return fmt.Sprintf("%d hours", hours)
 	}
+	return "hour"
+}
+
+func (m *LimitMenuItem) hide() {
+	m.percent.Hide()
+	m.reset.Hide()
+	m.forecast.Hide()
+	m.pace.Hide()
+}
+
+func addLimitMenuItem(percentText, resetText string) *LimitMenuItem {
+	item := &LimitMenuItem{
+		percent:  systray.AddMenuItem(percentText, ""),
+		reset:    systray.AddMenuItem(resetText, ""),
+		forecast: systray.AddMenuItem("", ""),
+		pace:     systray.AddMenuItem("", ""),
+	}
+	item.reset.Disable()
+	item.forecast.Disable()
+	item.forecast.Hide()
+	item.pace.Disable()
+	item.pace.Hide()
+	return item
+}
+
+func updateMenuItems() {
+	iconPercent := -1
+
+	for _, state := range profileStates {
+		if state.menu != nil {
+			state.menu.updateStatus(state.loadStatus())
+			if state.executor != nil {
+				state.menu.updateCircuit(state.executor.Breaker().Snapshot())
+			}
+			state.menu.updateTranscripts(state.loadTranscriptUsage())
+		}
 
-	// Update Week (All)
-	if menuRefs.weekAllPercent != nil {
-		menuRefs.weekAllPercent.SetTitle(fmt.Sprintf("Week (All)          %02d%%   %s", usage.WeekAllPercent, getUsageEmoji(usage.WeekAllPercent)))
+		data, err := state.loadUsageData()
+		if err != nil {
+			log.Printf("Failed to load usage data for profile %s: %v", profileName(state.profile), err)
+			continue
+		}
+
+		if state.menu != nil {
+			state.menu.update(data, state.loadForecasts(data))
+		}
+
+		// The icon follows the chosen profile, or the worst one
+		if session, ok := data.Limit(usage.SessionLimitID); ok {
+			if appConfig.IconProfile != "" {
+				if state.profile.Is(appConfig.IconProfile) {
+					iconPercent = session.Percent
+				}
+			} else if session.Percent > iconPercent {
+				iconPercent = session.Percent
+			}
+		}
 	}
-	if menuRefs.weekAllReset != nil {
-		menuRefs.weekAllReset.SetTitle(fmt.Sprintf("resets %s", removeTimezone(usage.WeekAllReset)))
+
+	if iconPercent >= 0 {
+		updateIcon(iconPercent)
 	}
+}
 
-	// Update Week (Opus) - only if menu items exist
-	if menuRefs.weekOpusPercent != nil {
-		menuRefs.weekOpusPercent.SetTitle(fmt.Sprintf("Week (Opus)       %02d%%   %s", usage.WeekOpusPercent, getUsageEmoji(usage.WeekOpusPercent)))
+// saveConfig writes the settings changed from the menu. When config.json
+// failed to load, the defaults in use would replace the user's file, so the
+// change only lasts until the app quits.
+func saveConfig() {
+	if configErr != nil {
+		log.Printf("Not saving config, config.json failed to load: %v", configErr)
+		return
 	}
-	if menuRefs.weekOpusReset != nil {
-		menuRefs.weekOpusReset.SetTitle(fmt.Sprintf("resets %s", removeTimezone(usage.WeekOpusReset)))
+	if err := config.SaveConfig(appConfig); err != nil {
+		log.Printf("Failed to save config: %v", err)
 	}
+}
 
-	// Update Week (Sonnet) - only if menu items exist
-	if menuRefs.weekSonnetPercent != nil {
-		menuRefs.weekSonnetPercent.SetTitle(fmt.Sprintf("Week (Sonnet)   %02d%%   %s", usage.WeekSonnetPercent, getUsageEmoji(usage.WeekSonnetPercent)))
+func (m *ProfileMenu) update(data *usage.UsageData, forecasts map[string]forecast.Forecast) {
+	m.updateWarning(data)
+	at := dataTime(data)
+
+	// Fill slots in order, hiding the ones left over
+	for i, item := range m.limits {
+		if i < len(data.Limits) {
+			f, ok := forecasts[data.Limits[i].ID]
+			item.show(data.Limits[i], f, ok)
+			item.showPace(data.Limits[i], at)
+		} else {
+			item.hide()
+		}
 	}
-	if menuRefs.weekSonnetReset != nil {
-		menuRefs.weekSonnetReset.SetTitle(fmt.Sprintf("resets %s", removeTimezone(usage.WeekSonnetReset)))
+	if len(data.Limits) > len(m.limits) {
+		log.Printf("Not enough menu slots for %d limits, restart to show all of them", len(data.Limits))
 	}
 
 	// Update Last update
-	if menuRefs.lastUpdate != nil {
-		menuRefs.lastUpdate.SetTitle(formatTimestamp(usage.Timestamp))
+	if m.lastUpdate != nil {
+		m.lastUpdate.SetTitle(formatTimestamp(data.Timestamp))
 	}
 }
 
+// updateWarning flags a /usage screen that doesn't show the blocks of its layout
+func (m *ProfileMenu) updateWarning(data *usage.UsageData) {
+	if data.Warning == "" {
+		m.warning.Hide()
+		return
+	}
+	m.warning.SetTitle("⚠️ Unrecognized /usage screen, numbers may be wrong")
+	m.warning.SetTooltip(data.Warning)
+	m.warning.Show()
+}
+
+// updateStatus shows the error of the last run, if it failed
+func (m *ProfileMenu) updateStatus(st *status.Status) {
+	if st == nil || st.LastError == nil {
+		m.errorLine.Hide()
+		m.errorHint.Hide()
+		return
+	}
+
+	m.errorLine.SetTitle("⚠️ " + st.LastError.Summary)
+	m.errorLine.SetTooltip(st.LastError.Message)
+	m.errorLine.Show()
+	m.errorHint.SetTitle(st.LastError.Hint)
+	m.errorHint.Show()
+}
+
+// updateCircuit shows when runs are held back after repeated failures
+func (m *ProfileMenu) updateCircuit(snapshot breaker.Snapshot) {
+	switch snapshot.State {
+	case breaker.Open:
+		m.circuit.SetTitle(fmt.Sprintf("⏸ Paused after %d failed runs, retrying at %s", snapshot.Failures, snapshot.RetryAt.Format("15:04")))
+	case breaker.HalfOpen:
+		m.circuit.SetTitle(fmt.Sprintf("⏸ %d failed runs in a row, retrying on the next update", snapshot.Failures))
+	default:
+		m.circuit.Hide()
+		return
+	}
+	m.circuit.SetTooltip("Use Update Now to try again right away")
+	m.circuit.Show()
+}
+
+// updateTranscripts shows what this week would have cost on the API and
+// which projects used the most
+func (m *ProfileMenu) updateTranscripts(u *transcriptUsage) {
+	if u == nil {
+		m.cost.Hide()
+		m.projects.Hide()
+		return
+	}
+
+	m.cost.SetTitle(fmt.Sprintf("API cost: %s today · %s this week", formatCost(&u.today), formatCost(&u.week)))
+	m.cost.Show()
+
+	if len(u.projects) == 0 {
+		m.projects.Hide()
+		return
+	}
+	for i, item := range m.projectSlots {
+		if i >= len(u.projects) {
+			item.Hide()
+			continue
+		}
+		e := u.byProject[u.projects[i]]
+		item.SetTitle(fmt.Sprintf("%s   %s tokens · %s", shortenPath(u.projects[i]), formatTokens(e.Tokens.Total()), formatCost(e)))
+		item.Show()
+	}
+	m.projects.Show()
+}
+
 func createMenuItems() {
-	usage, err := loadUsageData()
+	for _, state := range profileStates {
+		state.menu = createProfileMenu(state, len(profileStates) > 1)
+	}
+}
+
+// refreshTranscripts reads the transcripts of every profile and shows their
+// cost and top projects. Run it off the systray thread: the first scan of a
+// large projects directory can take a while.
+func refreshTranscripts() {
+	for _, state := range profileStates {
+		state.menu.updateTranscripts(state.loadTranscriptUsage())
+	}
+}
 
-	menuRefs = &MenuItemRefs{}
+func createProfileMenu(state *ProfileState, showHeader bool) *ProfileMenu {
+	data, err := state.loadUsageData()
 
-	var sessionText, sessionResetText string
-	var weekAllText, weekAllResetText string
-	var weekOpusText, weekOpusResetText string
-	var weekSonnetText, weekSonnetResetText string
-	var lastUpdateText string
-	var showOpus, showSonnet bool
+	menu := &ProfileMenu{}
+
+	if showHeader {
+		menu.header = systray.AddMenuItem(profileName(state.profile), "")
+		menu.header.Disable()
+	}
+
+	menu.errorLine = systray.AddMenuItem("", "")
+	menu.errorLine.Disable()
+	menu.errorHint = systray.AddMenuItem("", "")
+	menu.errorHint.Disable()
+	menu.circuit = systray.AddMenuItem("", "")
+	menu.circuit.Disable()
+	menu.circuit.Hide()
+	menu.warning = systray.AddMenuItem("", "")
+	menu.warning.Disable()
+	menu.warning.Hide()
+	menu.updateStatus(state.loadStatus())
 
 	if err != nil {
-		sessionText = "Session         Loading..."
-		sessionResetText = "resets: N/A"
-		weekAllText = "Week (All)      Loading..."
-		weekAllResetText = "resets: N/A"
-		weekOpusText = "Week (Opus)     Loading..."
-		weekOpusResetText = "resets: N/A"
-		weekSonnetText = "Week (Sonnet)   Loading..."
-		weekSonnetResetText = "resets: N/A"
-		lastUpdateText = "N/A"
-		showOpus = false
-		showSonnet = true // Default to showing Sonnet for new users
+		// Placeholders for new users until the first run finishes
+		for _, label := range []string{"Session", "Week (all models)", "Week (Sonnet only)"} {
+			menu.limits = append(menu.limits, addLimitMenuItem(fmt.Sprintf("%-18s Loading...", label), "resets: N/A"))
+			systray.AddSeparator()
+		}
 	} else {
-		sessionText = fmt.Sprintf("Session              %02d%%   %s", usage.SessionPercent, getUsageEmoji(usage.SessionPercent))
-		sessionResetText = fmt.Sprintf("resets %s", removeTimezone(usage.SessionReset))
-		weekAllText = fmt.Sprintf("Week (All)          %02d%%   %s", usage.WeekAllPercent, getUsageEmoji(usage.WeekAllPercent))
-		weekAllResetText = fmt.Sprintf("resets %s", removeTimezone(usage.WeekAllReset))
-		weekOpusText = fmt.Sprintf("Week (Opus)       %02d%%   %s", usage.WeekOpusPercent, getUsageEmoji(usage.WeekOpusPercent))
-		weekOpusResetText = fmt.Sprintf("resets %s", removeTimezone(usage.WeekOpusReset))
-		weekSonnetText = fmt.Sprintf("Week (Sonnet)   %02d%%   %s", usage.WeekSonnetPercent, getUsageEmoji(usage.WeekSonnetPercent))
-		weekSonnetResetText = fmt.Sprintf("resets %s", removeTimezone(usage.WeekSonnetReset))
-		lastUpdateText = formatTimestamp(usage.Timestamp)
-		showOpus = hasOpusAccess(usage)
-		showSonnet = hasSonnetAccess(usage)
-	}
-
-	// Session
-	menuRefs.sessionPercent = systray.AddMenuItem(sessionText, "")
-	menuRefs.sessionReset = systray.AddMenuItem(sessionResetText, "")
-	menuRefs.sessionReset.Disable()
-	systray.AddSeparator()
+		forecasts := state.loadForecasts(data)
+		for _, limit := range data.Limits {
+			item := addLimitMenuItem(formatLimit(limit), formatReset(limit))
+			f, ok := forecasts[limit.ID]
+			item.showForecast(f, ok)
+			item.showPace(limit, dataTime(data))
+			menu.limits = append(menu.limits, item)
+			systray.AddSeparator()
+		}
+		menu.updateWarning(data)
+	}
 
-	// Week (All)
-	menuRefs.weekAllPercent = systray.AddMenuItem(weekAllText, "")
-	menuRefs.weekAllReset = systray.AddMenuItem(weekAllResetText, "")
-	menuRefs.weekAllReset.Disable()
-	systray.AddSeparator()
+	for i := 0; i < spareLimitSlots; i++ {
+		item := addLimitMenuItem("", "")
+		item.hide()
+		menu.limits = append(menu.limits, item)
+	}
 
-	// Week (Opus) - only create if user has Opus access (old format)
-	if showOpus {
-		menuRefs.weekOpusPercent = systray.AddMenuItem(weekOpusText, "")
-		menuRefs.weekOpusReset = systray.AddMenuItem(weekOpusResetText, "")
-		menuRefs.weekOpusReset.Disable()
-		systray.AddSeparator()
+	lastUpdateText := "N/A"
+	if err == nil {
+		lastUpdateText = formatTimestamp(data.Timestamp)
+	}
+
+	menu.cost = systray.AddMenuItem("", "Estimated from claude's transcripts with API prices")
+	menu.cost.Disable()
+	menu.projects = systray.AddMenuItem("Top projects this week", "")
+	for i := 0; i < topProjectSlots; i++ {
+		item := menu.projects.AddSubMenuItem("", "")
+		item.Disable()
+		menu.projectSlots = append(menu.projectSlots, item)
 	}
+	// Filled in by refreshTranscripts, the first scan can take a while
+	menu.updateTranscripts(nil)
+
+	menu.lastUpdate = systray.AddMenuItem(lastUpdateText, "")
+	menu.lastUpdate.Disable()
 
-	// Week (Sonnet) - only create if user has Sonnet access (new format)
-	if showSonnet {
-		menuRefs.weekSonnetPercent = systray.AddMenuItem(weekSonnetText, "")
-		menuRefs.weekSonnetReset = systray.AddMenuItem(weekSonnetResetText, "")
-		menuRefs.weekSonnetReset.Disable()
+	if showHeader {
 		systray.AddSeparator()
 	}
 
-	menuRefs.lastUpdate = systray.AddMenuItem(lastUpdateText, "")
-	menuRefs.lastUpdate.Disable()
+	return menu
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/pricing"
+	"github.com/ribeirogab/claude-code-monitor/internal/transcript"
+)
+
+// dateLayout is the format of the -from and -to flags
+const dateLayout = "2006-01-02"
+
+// parseRange turns -from/-to dates (inclusive, local time) into a time
+// range, defaulting to the last days days
+func parseRange(from, to string, days int) (time.Time, time.Time, error) {
+	var start, end time.Time
+	if from != "" {
+		t, err := time.ParseInLocation(dateLayout, from, time.Local)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid -from date %q, expected YYYY-MM-DD", from)
+		}
+		start = t
+	} else {
+		start = transcript.StartOfDay(time.Now().AddDate(0, 0, -days+1))
+	}
+	if to != "" {
+		t, err := time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid -to date %q, expected YYYY-MM-DD", to)
+		}
+		end = t.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// shortenPath replaces the home directory with ~
+func shortenPath(path string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil || homeDir == "" {
+		return path
+	}
+	if path == homeDir || strings.HasPrefix(path, homeDir+string(filepath.Separator)) {
+		return "~" + strings.TrimPrefix(path, homeDir)
+	}
+	return path
+}
+
+// formatTokens formats a token count compactly, e.g. 4.1M
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.1fB", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// topProjects returns the projects with the most tokens in buckets, at most limit
+func topProjects(table *pricing.Table, buckets []transcript.Bucket, key func(transcript.Bucket) string, limit int) ([]string, map[string]*pricing.Estimate) {
+	estimates := table.Estimate(buckets, key)
+	keys := sortedKeys(estimates)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return estimates[keys[i]].Tokens.Total() > estimates[keys[j]].Tokens.Total()
+	})
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys, estimates
+}
+
+func runProjects(args []string) error {
+	fs := flag.NewFlagSet("projects", flag.ContinueOnError)
+	profileLabel := fs.String("profile", "", "profile label (default: first profile)")
+	days := fs.Int("days", 7, "number of days to include, when -from isn't set")
+	from := fs.String("from", "", "first day to include (YYYY-MM-DD)")
+	to := fs.String("to", "", "last day to include (YYYY-MM-DD)")
+	branches := fs.Bool("branches", false, "break projects down by git branch")
+	limit := fs.Int("limit", 0, "show at most this many rows")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	start, end, err := parseRange(*from, *to, *days)
+	if err != nil {
+		return err
+	}
+	profile, dir, err := findProfile(*profileLabel)
+	if err != nil {
+		return err
+	}
+	ix, err := openTranscripts(profile, dir)
+	if err != nil {
+		return err
+	}
+	table, err := loadPricing()
+	if err != nil {
+		return err
+	}
+
+	buckets := ix.Buckets(start, end)
+	key := transcript.ByProject
+	if *branches {
+		key = transcript.ByProjectBranch
+	}
+	keys, estimates := topProjects(table, buckets, key, *limit)
+	total := table.Total(buckets)
+
+	// Keys join project and branch, keep them apart for the table
+	branchOf := make(map[string]string)
+	projectOf := make(map[string]string)
+	for _, b := range buckets {
+		projectOf[key(b)] = b.Project
+		branchOf[key(b)] = b.Branch
+	}
+
+	if end.IsZero() {
+		fmt.Printf("Usage by project since %s\n\n", start.Format("Jan 2"))
+	} else {
+		fmt.Printf("Usage by project from %s to %s\n\n", start.Format("Jan 2"), end.AddDate(0, 0, -1).Format("Jan 2"))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if *branches {
+		fmt.Fprintln(w, "PROJECT\tBRANCH\tTOKENS\tSHARE\tCOST")
+	} else {
+		fmt.Fprintln(w, "PROJECT\tTOKENS\tSHARE\tCOST")
+	}
+	for _, k := range keys {
+		e := estimates[k]
+		share := 0.0
+		if total.Tokens.Total() > 0 {
+			share = float64(e.Tokens.Total()) / float64(total.Tokens.Total()) * 100
+		}
+
+		project := shortenPath(projectOf[k])
+		if project == "" {
+			project = "(unknown)"
+		}
+		if *branches {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.0f%%\t%s\n", project, branchOf[k], formatTokens(e.Tokens.Total()), share, formatCost(e))
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%.0f%%\t%s\n", project, formatTokens(e.Tokens.Total()), share, formatCost(e))
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/archive"
+)
+
+// captureArchiveDir holds a profile's archived raw captures
+const captureArchiveDir = "captures"
+
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	profileLabel := fs.String("profile", "", "profile label (default: first profile)")
+	verbose := fs.Bool("v", false, "also list captures that parse the same")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	_, dir, err := findProfile(*profileLabel)
+	if err != nil {
+		return err
+	}
+
+	// keep is irrelevant when only reading
+	entries, err := archive.New(filepath.Join(dir, captureArchiveDir), 0).List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No archived captures yet")
+		return nil
+	}
+
+	changed := 0
+	for i := range entries {
+		e := &entries[i]
+		diffs, err := archive.Replay(e)
+		if err != nil {
+			fmt.Printf("%s  %s\n  failed to replay: %v\n", e.ID, describeCapture(e), err)
+			changed++
+			continue
+		}
+		if len(diffs) == 0 {
+			if *verbose {
+				fmt.Printf("%s  %s  same\n", e.ID, describeCapture(e))
+			}
+			continue
+		}
+
+		changed++
+		fmt.Printf("%s  %s\n", e.ID, describeCapture(e))
+		for _, d := range diffs {
+			fmt.Printf("  %s\n", d)
+		}
+	}
+
+	fmt.Printf("\n%d of %d captures parse differently\n", changed, len(entries))
+	if changed > 0 {
+		return fmt.Errorf("%d captures parse differently", changed)
+	}
+	return nil
+}
+
+// describeCapture summarizes the metadata of a capture
+func describeCapture(e *archive.Entry) string {
+	version := e.CLIVersion
+	if version == "" {
+		version = "unknown"
+	}
+	status := e.ExitStatus
+	if status == "" {
+		status = "exit status unknown"
+	}
+	if e.CaptureError != "" {
+		return fmt.Sprintf("claude %s, %s, capture failed: %s", version, status, e.CaptureError)
+	}
+	return fmt.Sprintf("claude %s, %s", version, status)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/config"
+	"github.com/ribeirogab/claude-code-monitor/internal/transcript"
+)
+
+// transcriptIndexFile keeps the progress of reading a profile's transcripts
+const transcriptIndexFile = "transcript-index.json"
+
+// openTranscripts opens and updates the transcript index of a profile
+func openTranscripts(profile config.Profile, dir string) (*transcript.Index, error) {
+	dirs := transcript.ProjectsDirs(profile.ClaudeConfigDir)
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no claude transcripts found, run claude at least once")
+	}
+
+	ix, err := transcript.Open(dirs, filepath.Join(dir, transcriptIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	if err := ix.Update(); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+func runTokens(args []string) error {
+	fs := flag.NewFlagSet("tokens", flag.ContinueOnError)
+	profileLabel := fs.String("profile", "", "profile label (default: first profile)")
+	days := fs.Int("days", 7, "number of days to include")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	profile, dir, err := findProfile(*profileLabel)
+	if err != nil {
+		return err
+	}
+	ix, err := openTranscripts(profile, dir)
+	if err != nil {
+		return err
+	}
+
+	from := time.Now().AddDate(0, 0, -*days)
+	buckets := ix.Buckets(from, time.Time{})
+
+	fmt.Printf("Tokens since %s\n\n", from.Format("Jan 2 15:04"))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "MODEL\tINPUT\tOUTPUT\tCACHE READ\tCACHE WRITE\tTOTAL\t")
+	byModel := transcript.Sum(buckets, transcript.ByModel)
+	var total transcript.Tokens
+	for _, model := range sortedKeys(byModel) {
+		printTokensRow(w, model, byModel[model])
+		total = total.Add(byModel[model])
+	}
+	printTokensRow(w, "total", total)
+	w.Flush()
+
+	fmt.Println("\n5-hour windows")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "WINDOW\tINPUT\tOUTPUT\tCACHE READ\tCACHE WRITE\tTOTAL\t")
+	for _, win := range transcript.Windows(buckets) {
+		label := win.Start.Local().Format("Jan 2 15:04") + " - " + win.End.Local().Format("15:04")
+		printTokensRow(w, label, win.Total)
+	}
+	return w.Flush()
+}
+
+func printTokensRow(w *tabwriter.Writer, label string, t transcript.Tokens) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t\n", label, t.Input, t.Output, t.CacheRead, t.CacheCreation, t.Total())
+}
+
+// sortedKeys returns the keys of m in alphabetical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
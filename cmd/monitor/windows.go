@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/history"
+)
+
+// windowTimeLayout is how window boundaries are shown
+const windowTimeLayout = "Jan 2 15:04"
+
+func runWindows(args []string) error {
+	fs := flag.NewFlagSet("windows", flag.ContinueOnError)
+	profileLabel := fs.String("profile", "", "profile label (default: first profile)")
+	metric := fs.String("metric", "", "limit id to show, e.g. session (default: all)")
+	days := fs.Int("days", 7, "number of days to include, when -from isn't set")
+	from := fs.String("from", "", "first day to include (YYYY-MM-DD)")
+	to := fs.String("to", "", "last day to include (YYYY-MM-DD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	start, end, err := parseRange(*from, *to, *days)
+	if err != nil {
+		return err
+	}
+	_, dir, err := findProfile(*profileLabel)
+	if err != nil {
+		return err
+	}
+
+	store := history.New(filepath.Join(dir, history.DirName))
+	query := history.Query{From: start, To: end}
+	if *metric != "" {
+		query.Metrics = []string{*metric}
+	}
+	windows, err := store.Windows(query)
+	if err != nil {
+		return err
+	}
+	if len(windows) == 0 {
+		fmt.Println("No usage windows in this range yet, they're recorded as the history grows")
+		return nil
+	}
+
+	// Windows come sorted by metric
+	now := time.Now()
+	for i := 0; i < len(windows); {
+		j := i
+		for j < len(windows) && windows[j].Metric == windows[i].Metric {
+			j++
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := printWindows(windows[i].Metric, windows[i:j], end, now); err != nil {
+			return err
+		}
+		i = j
+	}
+	return nil
+}
+
+// printWindows prints the window summaries of a metric as a table. to is
+// the end of the range, zero when it's open.
+func printWindows(metric string, windows []history.Window, to, now time.Time) error {
+	fmt.Println(metric)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "START\tEND\tPEAK\t>%d%%\tAT 100%%\tHIT AT\tRESET\n", history.HighPercent)
+	for _, win := range windows {
+		end, reason := "-", ""
+		switch {
+		case win.End != nil:
+			end = win.End.Local().Format(windowTimeLayout)
+			reason = strings.ReplaceAll(win.Reason, "_", " ")
+		case isCurrent(win, to, now):
+			end = "(current)"
+		case win.ResetsAt != nil && !win.ResetsAt.After(now):
+			// Reset with no run after it in the range to show the drop
+			end = win.ResetsAt.Local().Format(windowTimeLayout)
+			reason = "reported"
+		}
+		hitAt := "-"
+		if win.HitAt != nil {
+			hitAt = win.HitAt.Local().Format(windowTimeLayout)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d%%\t%s\t%s\t%s\t%s\n",
+			win.Start.Local().Format(windowTimeLayout), end, win.Peak,
+			formatDuration(win.High), formatDuration(win.AtLimit), hitAt, reason)
+	}
+	return w.Flush()
+}
+
+// isCurrent reports whether the last window of a range is still open: the
+// range reaches now and the reset the CLI reported hasn't come yet
+func isCurrent(win history.Window, to, now time.Time) bool {
+	if !to.IsZero() && to.Before(now) {
+		return false
+	}
+	return win.ResetsAt == nil || win.ResetsAt.After(now)
+}
+
+// formatDuration formats a duration in hours and minutes, e.g. 1h30m
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	switch {
+	case d == 0:
+		return "-"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
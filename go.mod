@@ -2,7 +2,10 @@ module github.com/ribeirogab/claude-code-monitor
 
 go 1.25.4
 
-require github.com/getlantern/systray v1.2.2
+require (
+	github.com/creack/pty v1.1.24
+	github.com/getlantern/systray v1.2.2
+)
 
 require (
 	github.com/getlantern/context v0.0.0-20190109183933-c447772a6520 // indirect
@@ -0,0 +1,164 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/fsutil"
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+const (
+	captureExt = ".log.gz"
+	metaExt    = ".json"
+
+	// idLayout names captures by their UTC timestamp, so they sort by age
+	idLayout = "20060102T150405.000Z"
+)
+
+// Meta describes an archived capture and what the parser made of it at the time
+type Meta struct {
+	ID         string    `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	CLIVersion string    `json:"cli_version,omitempty"`
+	// ExitStatus is how claude exited, e.g. "exit status 0" or "signal: killed"
+	ExitStatus string `json:"exit_status,omitempty"`
+
+	// CaptureError is why the run failed before parsing, e.g. a timeout or a
+	// login prompt. Replay can't reproduce it, so it's kept apart from Error.
+	CaptureError string `json:"capture_error,omitempty"`
+
+	// Result of parsing the capture when it was taken
+	Limits  []usage.Limit `json:"limits,omitempty"`
+	Layout  string        `json:"layout,omitempty"`
+	Warning string        `json:"warning,omitempty"`
+	// Error is the parse error, the only error Replay compares
+	Error string `json:"error,omitempty"`
+}
+
+// Entry is a capture in the archive
+type Entry struct {
+	Meta
+	path string
+}
+
+// Archive keeps the last raw /usage captures, gzip compressed, each with a
+// metadata file next to it
+type Archive struct {
+	dir  string
+	keep int
+}
+
+// New creates an Archive in dir keeping the newest keep captures
+func New(dir string, keep int) *Archive {
+	return &Archive{dir: dir, keep: keep}
+}
+
+// Add stores a capture and prunes the oldest ones beyond the limit
+func (a *Archive) Add(raw []byte, meta Meta) error {
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return err
+	}
+
+	if meta.Timestamp.IsZero() {
+		meta.Timestamp = time.Now()
+	}
+	meta.Timestamp = meta.Timestamp.UTC()
+	meta.ID = meta.Timestamp.Format(idLayout)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.ModTime = meta.Timestamp
+	if _, err := zw.Write(raw); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	metaJSON, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The capture goes first, an entry is only listed once its metadata exists
+	if err := fsutil.WriteFileAtomic(filepath.Join(a.dir, meta.ID+captureExt), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write capture: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(a.dir, meta.ID+metaExt), metaJSON, 0644); err != nil {
+		return fmt.Errorf("failed to write capture metadata: %w", err)
+	}
+
+	return a.prune()
+}
+
+// List returns the archived captures, newest first
+func (a *Archive) List() ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(a.dir, "*"+metaExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, metaPath := range files {
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			return nil, err
+		}
+		var meta Meta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", metaPath, err)
+		}
+		entries = append(entries, Entry{
+			Meta: meta,
+			path: strings.TrimSuffix(metaPath, metaExt) + captureExt,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID > entries[j].ID })
+	return entries, nil
+}
+
+// Raw returns the uncompressed capture
+func (e *Entry) Raw() ([]byte, error) {
+	f, err := os.Open(e.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", e.path, err)
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
+
+// prune removes captures beyond the newest keep
+func (a *Archive) prune() error {
+	entries, err := a.List()
+	if err != nil {
+		return err
+	}
+	if a.keep <= 0 || len(entries) <= a.keep {
+		return nil
+	}
+
+	for _, e := range entries[a.keep:] {
+		os.Remove(strings.TrimSuffix(e.path, captureExt) + metaExt)
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"fmt"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+// Result returns the metadata fields describing a parse outcome
+func Result(data *usage.UsageData, err error) Meta {
+	if err != nil {
+		return Meta{Error: err.Error()}
+	}
+	return Meta{Limits: data.Limits, Layout: data.Layout, Warning: data.Warning}
+}
+
+// Replay parses a capture again with the current parser and lists how the
+// outcome differs from the one recorded when it was archived. An empty list
+// means it parses the same. Only the parse result is compared: how the
+// capture ended (CaptureError) is a fact about the run, not the parser.
+func Replay(e *Entry) ([]string, error) {
+	raw, err := e.Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	now := Result(usage.ParseVersion(raw, e.CLIVersion))
+	return compare(e.Meta, now), nil
+}
+
+// compare describes the differences between two parse outcomes
+func compare(before, after Meta) []string {
+	var diffs []string
+
+	switch {
+	case before.Error != "" && after.Error == "":
+		diffs = append(diffs, fmt.Sprintf("now parses, failed before with %q", before.Error))
+	case before.Error == "" && after.Error != "":
+		diffs = append(diffs, fmt.Sprintf("now fails with %q", after.Error))
+	case before.Error != after.Error:
+		diffs = append(diffs, fmt.Sprintf("error changed from %q to %q", before.Error, after.Error))
+	}
+
+	if before.Layout != after.Layout {
+		diffs = append(diffs, fmt.Sprintf("layout changed from %q to %q", before.Layout, after.Layout))
+	}
+	if before.Warning != after.Warning {
+		diffs = append(diffs, fmt.Sprintf("warning changed from %q to %q", before.Warning, after.Warning))
+	}
+
+	afterByID := make(map[string]usage.Limit)
+	for _, l := range after.Limits {
+		afterByID[l.ID] = l
+	}
+	for _, b := range before.Limits {
+		a, ok := afterByID[b.ID]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: no longer found", b.ID))
+			continue
+		}
+		delete(afterByID, b.ID)
+
+		if a.Percent != b.Percent {
+			diffs = append(diffs, fmt.Sprintf("%s: percent %d -> %d", b.ID, b.Percent, a.Percent))
+		}
+		if a.Reset != b.Reset {
+			diffs = append(diffs, fmt.Sprintf("%s: reset %q -> %q", b.ID, b.Reset, a.Reset))
+		}
+		if a.Name != b.Name {
+			diffs = append(diffs, fmt.Sprintf("%s: name %q -> %q", b.ID, b.Name, a.Name))
+		}
+	}
+	for _, a := range after.Limits {
+		if _, ok := afterByID[a.ID]; ok {
+			diffs = append(diffs, fmt.Sprintf("%s: new limit (%d%%)", a.ID, a.Percent))
+		}
+	}
+
+	return diffs
+}
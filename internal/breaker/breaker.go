@@ -0,0 +1,118 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCooldown is how long the breaker stays open after it trips
+	defaultCooldown = 5 * time.Minute
+
+	// defaultMaxCooldown caps the cooldown, which doubles with every
+	// failure after the breaker tripped
+	defaultMaxCooldown = time.Hour
+)
+
+// State is the position of a Breaker
+type State string
+
+const (
+	// Closed lets every run through
+	Closed State = "closed"
+	// Open skips runs until the cooldown is over
+	Open State = "open"
+	// HalfOpen lets the next run through to test whether it recovered
+	HalfOpen State = "half_open"
+)
+
+// Config configures a Breaker
+type Config struct {
+	// Threshold is how many failures in a row trip the breaker, 0 disables it
+	Threshold int
+	// Cooldown is how long the breaker stays open after tripping
+	Cooldown time.Duration
+	// MaxCooldown caps the cooldown as failures keep piling up
+	MaxCooldown time.Duration
+}
+
+// Breaker is a circuit breaker that stops runs after repeated failures and
+// lets them through again, less and less often, until one succeeds
+type Breaker struct {
+	config Config
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// Snapshot is the state of a Breaker at a point in time
+type Snapshot struct {
+	State State
+	// Failures is how many runs failed in a row
+	Failures int
+	// RetryAt is when an open breaker lets the next run through
+	RetryAt time.Time
+}
+
+// New creates a new Breaker
+func New(config Config) *Breaker {
+	if config.Cooldown <= 0 {
+		config.Cooldown = defaultCooldown
+	}
+	if config.MaxCooldown < config.Cooldown {
+		config.MaxCooldown = max(defaultMaxCooldown, config.Cooldown)
+	}
+	return &Breaker{config: config}
+}
+
+// Success closes the breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
+
+// Failure records a failed run, opening the breaker once the threshold is
+// reached. Every further failure doubles the cooldown.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.config.Threshold <= 0 || b.failures < b.config.Threshold {
+		return
+	}
+
+	cooldown := b.config.Cooldown
+	for i := b.config.Threshold; i < b.failures && cooldown < b.config.MaxCooldown; i++ {
+		cooldown *= 2
+	}
+	b.openUntil = time.Now().Add(min(cooldown, b.config.MaxCooldown))
+}
+
+// Probe ends the cooldown early so the next run goes through, e.g. when the
+// user asks for an update. A failure opens the breaker again.
+func (b *Breaker) Probe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.openUntil = time.Time{}
+}
+
+// Snapshot returns the current state
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Snapshot{State: Closed, Failures: b.failures}
+	if b.config.Threshold <= 0 || b.failures < b.config.Threshold {
+		return s
+	}
+	if time.Now().Before(b.openUntil) {
+		s.State = Open
+		s.RetryAt = b.openUntil
+	} else {
+		s.State = HalfOpen
+	}
+	return s
+}
@@ -0,0 +1,370 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/creack/pty"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+// ErrClaudeNotFound is returned when the claude CLI can't be located
+var ErrClaudeNotFound = errors.New("claude CLI not found")
+
+// versionTimeout bounds how long `claude --version` may take
+const versionTimeout = 15 * time.Second
+
+// Candidate is a location checked for the claude CLI
+type Candidate struct {
+	Path string
+	// Source is where the location comes from, e.g. "nvm" or "PATH"
+	Source string
+	// Reason is why the candidate was rejected, empty when it's usable
+	Reason string
+}
+
+// Discovery is the outcome of looking for the claude CLI
+type Discovery struct {
+	// Path is the chosen claude, empty when none was usable
+	Path       string
+	Candidates []Candidate
+}
+
+// NotFoundError is returned when no candidate is usable. It lists every
+// location tried.
+type NotFoundError struct {
+	Candidates []Candidate
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Candidates) == 1 {
+		c := e.Candidates[0]
+		return fmt.Sprintf("%s: %s %s", ErrClaudeNotFound, c.Path, c.Reason)
+	}
+	return fmt.Sprintf("%s (tried %d locations, run claude-code-monitor find-claude for details)", ErrClaudeNotFound, len(e.Candidates))
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return ErrClaudeNotFound
+}
+
+// FindClaude returns the claude CLI to run. An explicit path is used as is
+// when it's executable; otherwise the usual install locations are searched.
+func FindClaude(explicit string) (string, error) {
+	d := Discover(explicit)
+	if d.Path == "" {
+		return "", &NotFoundError{Candidates: d.Candidates}
+	}
+	return d.Path, nil
+}
+
+// Discover checks every known install location of the claude CLI, in order
+// of preference, and records why each candidate was rejected. When explicit
+// is set, it's the only candidate.
+func Discover(explicit string) *Discovery {
+	d := &Discovery{}
+
+	if explicit != "" {
+		d.check(explicit, "claude_path")
+		return d
+	}
+
+	for _, c := range candidates() {
+		d.check(c.Path, c.Source)
+	}
+	return d
+}
+
+// check records a candidate, choosing it when it's the first usable one
+func (d *Discovery) check(path, source string) {
+	for _, c := range d.Candidates {
+		if c.Path == path {
+			return
+		}
+	}
+
+	reason := rejectReason(path)
+	if reason == "" && d.Path != "" {
+		reason = "usable, but " + d.Path + " comes first"
+	}
+	if reason == "" {
+		d.Path = path
+	}
+	d.Candidates = append(d.Candidates, Candidate{Path: path, Source: source, Reason: reason})
+}
+
+// candidates lists the install locations of claude, most preferred first.
+// Version managers are listed newest node version first.
+func candidates() []Candidate {
+	homeDir, _ := os.UserHomeDir()
+	home := func(elem ...string) string {
+		return filepath.Join(append([]string{homeDir}, elem...)...)
+	}
+	envOr := func(name, fallback string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		return fallback
+	}
+
+	var list []Candidate
+	add := func(source string, paths ...string) {
+		for _, p := range paths {
+			list = append(list, Candidate{Path: p, Source: source})
+		}
+	}
+
+	add("claude local install", home(".claude", "local", "claude"))
+	add("native installer", home(".local", "bin", "claude"))
+	add("homebrew", "/opt/homebrew/bin/claude")
+	add("system", "/usr/local/bin/claude")
+
+	for _, prefix := range npmPrefixes(homeDir) {
+		add("npm prefix", filepath.Join(prefix, "bin", "claude"))
+	}
+
+	add("volta", filepath.Join(envOr("VOLTA_HOME", home(".volta")), "bin", "claude"))
+	add("bun", filepath.Join(envOr("BUN_INSTALL", home(".bun")), "bin", "claude"))
+
+	add("nvm", newestFirst(filepath.Join(envOr("NVM_DIR", home(".nvm")), "versions", "node", "*", "bin", "claude"))...)
+
+	fnmDirs := []string{
+		home(".local", "share", "fnm"),
+		home("Library", "Application Support", "fnm"),
+		home(".fnm"),
+	}
+	if dir := os.Getenv("FNM_DIR"); dir != "" {
+		fnmDirs = append([]string{dir}, fnmDirs...)
+	}
+	for _, dir := range fnmDirs {
+		add("fnm", newestFirst(filepath.Join(dir, "node-versions", "*", "installation", "bin", "claude"))...)
+	}
+
+	asdfDir := envOr("ASDF_DATA_DIR", home(".asdf"))
+	add("asdf", newestFirst(filepath.Join(asdfDir, "installs", "nodejs", "*", "bin", "claude"))...)
+	add("asdf", filepath.Join(asdfDir, "shims", "claude"))
+
+	miseDir := envOr("MISE_DATA_DIR", home(".local", "share", "mise"))
+	add("mise", newestFirst(filepath.Join(miseDir, "installs", "node", "*", "bin", "claude"))...)
+	add("mise", filepath.Join(miseDir, "shims", "claude"))
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" {
+			add("PATH", filepath.Join(dir, "claude"))
+		}
+	}
+
+	return list
+}
+
+// npmPrefixes returns the global npm prefixes claude may be installed under
+func npmPrefixes(homeDir string) []string {
+	var prefixes []string
+	if prefix := os.Getenv("NPM_CONFIG_PREFIX"); prefix != "" {
+		prefixes = append(prefixes, prefix)
+	}
+
+	// A prefix set with `npm config set prefix` ends up in ~/.npmrc
+	if data, err := os.ReadFile(filepath.Join(homeDir, ".npmrc")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			key, value, ok := strings.Cut(line, "=")
+			if !ok || strings.TrimSpace(key) != "prefix" {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			if strings.HasPrefix(value, "~/") {
+				value = filepath.Join(homeDir, value[2:])
+			}
+			prefixes = append(prefixes, value)
+		}
+	}
+
+	return append(prefixes,
+		filepath.Join(homeDir, ".npm-global"),
+		filepath.Join(homeDir, ".npm"),
+	)
+}
+
+// newestFirst expands a glob over node version directories and sorts the
+// matches by version, newest first
+func newestFirst(pattern string) []string {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return usage.CompareVersions(matches[i], matches[j]) > 0
+	})
+	return matches
+}
+
+// rejectReason returns why path can't be used as claude, or "" if it can
+func rejectReason(path string) string {
+	if _, err := os.Lstat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "does not exist"
+		}
+		return err.Error()
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "broken symlink"
+	}
+	if info.IsDir() {
+		return "is a directory"
+	}
+	if !info.Mode().IsRegular() {
+		return "not a regular file"
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return "not executable"
+	}
+	return ""
+}
+
+// ClaudeVersion runs `claude --version` and returns the version it prints
+func ClaudeVersion(ctx context.Context, claudePath, configDir string) (string, error) {
+	return launcher{claudePath: claudePath, configDir: configDir}.version(ctx)
+}
+
+// launcher builds the commands that run claude, either locally or through a
+// transport command such as `ssh buildbox` or `docker exec -it devbox`
+type launcher struct {
+	claudePath string
+	configDir  string
+	transport  []string
+}
+
+// command returns the command running claude with args
+func (l launcher) command(args ...string) *exec.Cmd {
+	if len(l.transport) > 0 {
+		// The environment of the other side is its own, only the terminal type
+		// is passed along (ssh forwards it, docker needs -e TERM)
+		argv := append(append(append([]string(nil), l.transport[1:]...), l.claudePath), args...)
+		cmd := exec.Command(l.transport[0], argv...)
+		cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+		return cmd
+	}
+
+	cmd := exec.Command(l.claudePath, args...)
+	cmd.Env = claudeEnv(l.claudePath, l.configDir)
+	return cmd
+}
+
+// version runs `claude --version`. Through a transport it runs in a
+// pseudo-terminal, since transports like `docker exec -it` insist on one.
+func (l launcher) version(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+
+	cmd := l.command("--version")
+
+	var output []byte
+	var err error
+	if len(l.transport) > 0 {
+		output, err = runInPTY(ctx, cmd)
+	} else {
+		output, err = runWithContext(ctx, cmd)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to run claude --version: %w", err)
+	}
+
+	version, ok := usage.ParseCLIVersion(string(output))
+	if !ok {
+		return "", fmt.Errorf("unexpected claude --version output: %q", strings.TrimSpace(string(output)))
+	}
+	return version, nil
+}
+
+// runWithContext runs cmd and returns its stdout, killing its process group
+// when ctx is done
+func runWithContext(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	setProcessGroup(cmd)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if err := waitWithContext(ctx, cmd); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// runInPTY runs cmd in a pseudo-terminal and returns everything it printed.
+// The pty makes it a session leader, so its process group can be killed.
+func runInPTY(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	output := newOutputBuffer()
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		// Reading fails with EIO once the process is gone, which just means EOF
+		io.Copy(output, ptmx)
+	}()
+
+	waitErr := waitWithContext(ctx, cmd)
+	if waitErr == nil {
+		// Give the reader a moment to drain what's left. A transport like ssh
+		// may keep the pty open after the command is gone.
+		select {
+		case <-readDone:
+		case <-time.After(time.Second):
+		}
+	}
+	// Closing doesn't interrupt a pending read everywhere (darwin), so the
+	// reader may outlive this call; output is a locked copy either way
+	ptmx.Close()
+
+	if waitErr != nil {
+		return nil, waitErr
+	}
+	return output.Bytes(), nil
+}
+
+// waitWithContext waits for cmd, killing its process group when ctx is done
+func waitWithContext(ctx context.Context, cmd *exec.Cmd) error {
+	waitDone := make(chan error, 1)
+	go func() {
+		waitDone <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-waitDone:
+		return err
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-waitDone
+		return ctx.Err()
+	}
+}
+
+// claudeEnv returns the environment claude runs with. claude is a node
+// script, so node must be found next to it (e.g. NVM installs).
+func claudeEnv(claudePath, configDir string) []string {
+	env := append(os.Environ(),
+		"PATH="+filepath.Dir(claudePath)+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"TERM=xterm-256color",
+	)
+	if configDir != "" {
+		env = append(env, "CLAUDE_CONFIG_DIR="+configDir)
+	}
+	return env
+}
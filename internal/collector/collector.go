@@ -0,0 +1,12 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+// Collector gathers the current usage data from some source
+type Collector interface {
+	Collect(ctx context.Context) (*usage.UsageData, error)
+}
@@ -0,0 +1,227 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/screen"
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+// TimeoutError is returned when a collection run exceeds its deadline. It
+// carries whatever output was captured before the process was killed.
+type TimeoutError struct {
+	Timeout time.Duration
+	Output  []byte
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("collection timed out after %s (%d bytes of output captured)", e.Timeout, len(e.Output))
+	}
+	return fmt.Sprintf("collection timed out (%d bytes of output captured)", len(e.Output))
+}
+
+// Screen renders the partial output as it was shown in the terminal
+func (e *TimeoutError) Screen() string {
+	term := screen.New(usage.ScreenCols, usage.ScreenRows)
+	term.Write(e.Output)
+	return term.String()
+}
+
+// Unwrap lets errors.Is(err, context.DeadlineExceeded) match
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// contextError converts a finished context into the error returned to
+// callers: a TimeoutError for deadlines, the context error otherwise
+func contextError(ctx context.Context, output []byte) error {
+	if ctx.Err() != context.DeadlineExceeded {
+		return ctx.Err()
+	}
+
+	var timeout time.Duration
+	if v, ok := ctx.Value(timeoutKey{}).(time.Duration); ok {
+		timeout = v
+	}
+	return &TimeoutError{Timeout: timeout, Output: output}
+}
+
+type timeoutKey struct{}
+
+// WithTimeout returns a context that expires after timeout and remembers the
+// duration, so a TimeoutError can report it
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, timeoutKey{}, timeout)
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Kind classifies why a collection failed
+type Kind string
+
+const (
+	KindCLINotFound       Kind = "cli_not_found"
+	KindNotLoggedIn       Kind = "not_logged_in"
+	KindPromptBlocking    Kind = "prompt_blocking"
+	KindSessionNotFound   Kind = "session_not_found"
+	KindParseFailed       Kind = "parse_failed"
+	KindTimeout           Kind = "timeout"
+	KindDependencyMissing Kind = "dependency_missing"
+	KindUnknown           Kind = "unknown"
+)
+
+// kindInfo is the short description and default remediation hint of a Kind
+var kindInfo = map[Kind]struct{ summary, hint string }{
+	KindCLINotFound: {
+		"Claude CLI not found",
+		"Install Claude Code (npm install -g @anthropic-ai/claude-code) or set claude_path in config.json",
+	},
+	KindNotLoggedIn: {
+		"Not logged in",
+		"Run claude in a terminal and log in with /login",
+	},
+	KindPromptBlocking: {
+		"Claude is waiting on a prompt",
+		"Run claude in a terminal and complete the prompt it shows",
+	},
+	KindSessionNotFound: {
+		"Usage screen not shown",
+		"Check that claude /usage works in a terminal; the raw output is in claude-code-usage.log",
+	},
+	KindParseFailed: {
+		"Could not read usage screen",
+		"The /usage layout may have changed; check claude-code-usage.log and update the monitor",
+	},
+	KindTimeout: {
+		"Collection timed out",
+		"Check your network connection or raise collector.timeout_seconds in config.json",
+	},
+	KindDependencyMissing: {
+		"Missing dependency",
+		"Install the missing tool, or switch to the pty collector which needs none",
+	},
+	KindUnknown: {
+		"Collection failed",
+		"See monitor.log in ~/.claude-code-monitor for details",
+	},
+}
+
+// Summary returns a short human readable description of the kind
+func (k Kind) Summary() string {
+	if info, ok := kindInfo[k]; ok {
+		return info.summary
+	}
+	return kindInfo[KindUnknown].summary
+}
+
+// Hint returns the default remediation hint of the kind
+func (k Kind) Hint() string {
+	if info, ok := kindInfo[k]; ok {
+		return info.hint
+	}
+	return kindInfo[KindUnknown].hint
+}
+
+// Retryable reports whether a failure of the kind may go away on its own.
+// Failures that need the user to act aren't worth retrying.
+func (k Kind) Retryable() bool {
+	switch k {
+	case KindCLINotFound, KindNotLoggedIn, KindPromptBlocking, KindDependencyMissing:
+		return false
+	}
+	return true
+}
+
+// Error is a collection failure of a known kind, with a hint on how to fix it
+type Error struct {
+	Kind Kind
+	Hint string
+	Err  error
+}
+
+// newError wraps err as a failure of the given kind with its default hint
+func newError(kind Kind, err error) *Error {
+	return &Error{Kind: kind, Hint: kind.Hint(), Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// prompt is text claude shows when it needs user input before doing anything
+type prompt struct {
+	text string
+	kind Kind
+	hint string
+}
+
+var prompts = []prompt{
+	{"Select login method", KindNotLoggedIn, ""},
+	{"Please run /login", KindNotLoggedIn, ""},
+	{"Invalid API key", KindNotLoggedIn, ""},
+	{"OAuth token has expired", KindNotLoggedIn, ""},
+	{"Do you trust the files in this folder", KindPromptBlocking, "Run claude once in ~/.claude-code-monitor/workspace and accept the trust prompt"},
+	{"Is this a project you created or one you trust", KindPromptBlocking, "Run claude once in ~/.claude-code-monitor/workspace and accept the trust prompt"},
+	{"Choose the text style", KindPromptBlocking, "Run claude in a terminal once to finish its first-run setup"},
+	{"Use Claude Code's terminal setup", KindPromptBlocking, "Run claude in a terminal once to finish its first-run setup"},
+}
+
+// detectPrompt looks for a blocking prompt on a rendered screen
+func detectPrompt(contains func(text string) bool, err error) (*Error, bool) {
+	for _, p := range prompts {
+		if !contains(p.text) {
+			continue
+		}
+		e := newError(p.kind, fmt.Errorf("%s (%q is on screen): %w", p.kind.Summary(), p.text, err))
+		if p.hint != "" {
+			e.Hint = p.hint
+		}
+		return e, true
+	}
+	return nil, false
+}
+
+// Classify turns any collection error into an *Error. Errors that are
+// already classified are returned as is.
+func Classify(err error) *Error {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified
+	}
+
+	var timeoutErr *TimeoutError
+	switch {
+	case errors.As(err, &timeoutErr):
+		// claude sitting on a prompt until the deadline is the prompt's fault
+		term := screen.New(usage.ScreenCols, usage.ScreenRows)
+		term.Write(timeoutErr.Output)
+		if e, ok := detectPrompt(term.Contains, err); ok {
+			return e
+		}
+		return newError(KindTimeout, err)
+	case errors.Is(err, ErrClaudeNotFound):
+		return newError(KindCLINotFound, err)
+	case errors.Is(err, usage.ErrSessionNotFound):
+		return newError(KindSessionNotFound, err)
+	case errors.Is(err, usage.ErrPercentNotFound):
+		return newError(KindParseFailed, err)
+	case errors.Is(err, exec.ErrNotFound):
+		return newError(KindDependencyMissing, err)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return newError(KindParseFailed, err)
+	}
+
+	return newError(KindUnknown, err)
+}
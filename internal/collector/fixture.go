@@ -0,0 +1,39 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+// FixtureCollector replays a raw /usage capture from disk, such as a saved
+// claude-code-usage.log. Useful for testing without a claude login.
+type FixtureCollector struct {
+	path string
+}
+
+// NewFixture creates a new FixtureCollector for the capture at path
+func NewFixture(path string) *FixtureCollector {
+	return &FixtureCollector{path: path}
+}
+
+// Collect parses the capture as if it had just been printed by claude
+func (c *FixtureCollector) Collect(ctx context.Context) (*usage.UsageData, error) {
+	raw, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := usage.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	data.Timestamp = now.UTC().Format(time.RFC3339)
+	data.ResolveResets(now)
+
+	return data, nil
+}
@@ -0,0 +1,336 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/archive"
+	"github.com/ribeirogab/claude-code-monitor/internal/screen"
+	"github.com/ribeirogab/claude-code-monitor/internal/trust"
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+const (
+	// How long the output must stay quiet after the usage screen shows up
+	settleDelay = 750 * time.Millisecond
+
+	// How long to wait for claude to exit after asking it to
+	exitGrace = 5 * time.Second
+)
+
+// errWaitingForInput is reported when claude shows a prompt instead of the usage screen
+var errWaitingForInput = errors.New("claude is waiting for input")
+
+// PTYConfig holds PTYCollector configuration
+type PTYConfig struct {
+	// ClaudePath is the claude CLI to run. Empty means it's discovered on
+	// every run.
+	ClaudePath string
+
+	// WorkDir is the dedicated directory claude is started from. It's trusted
+	// once and then left alone, so runs don't touch the claude config.
+	WorkDir string
+
+	// ConfigDir is passed to claude as CLAUDE_CONFIG_DIR when not empty
+	ConfigDir string
+
+	// TransientTrust removes the trust entry for WorkDir after every run
+	// instead of keeping it
+	TransientTrust bool
+
+	// LedgerPath records the trust entries added by the monitor
+	LedgerPath string
+
+	// LogPath receives the raw terminal output of the last run when not empty
+	LogPath string
+
+	// Archive keeps the raw output of past runs when not nil
+	Archive *archive.Archive
+
+	// Transport is a command prefix claude is run through, e.g.
+	// ["ssh", "-t", "buildbox"]. claude then runs on the other side: it isn't
+	// searched for locally, and WorkDir, ConfigDir and trust don't apply.
+	Transport []string
+}
+
+// PTYCollector runs `claude /usage` in a pseudo-terminal and parses the screen it prints
+type PTYCollector struct {
+	cfg PTYConfig
+}
+
+// NewPTY creates a new PTYCollector
+func NewPTY(cfg PTYConfig) *PTYCollector {
+	return &PTYCollector{cfg: cfg}
+}
+
+// Collect starts claude, waits for the usage screen and returns the parsed data
+func (c *PTYCollector) Collect(ctx context.Context) (*usage.UsageData, error) {
+	var claudePath string
+	if len(c.cfg.Transport) > 0 {
+		// claude runs on the other side, where it's found through its PATH
+		claudePath = c.cfg.ClaudePath
+		if claudePath == "" {
+			claudePath = "claude"
+		}
+	} else {
+		path, release, err := c.prepareLocal()
+		if err != nil {
+			return nil, err
+		}
+		claudePath = path
+		if c.cfg.TransientTrust {
+			defer func() {
+				if err := release(); err != nil {
+					log.Printf("Failed to remove trust for %s: %v", c.cfg.WorkDir, err)
+				}
+			}()
+		}
+	}
+
+	version, err := c.launcher(claudePath).version(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx, nil)
+		}
+		log.Printf("Failed to get claude version: %v", err)
+	}
+
+	output, exitStatus, err := c.capture(ctx, claudePath)
+	if c.cfg.LogPath != "" {
+		if writeErr := os.WriteFile(c.cfg.LogPath, output, 0644); writeErr != nil {
+			log.Printf("Failed to write raw output: %v", writeErr)
+		}
+	}
+
+	// The output is parsed even when the capture failed, so the archive
+	// records what the parser makes of it apart from how the run ended
+	data, parseErr := usage.ParseVersion(output, version)
+	c.archive(output, version, exitStatus, data, parseErr, err)
+	if err != nil {
+		return nil, err
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if data.Warning != "" {
+		log.Printf("Warning: %s", data.Warning)
+	}
+	now := time.Now()
+	data.Timestamp = now.UTC().Format(time.RFC3339)
+	data.ResolveResets(now)
+
+	return data, nil
+}
+
+// prepareLocal finds claude and trusts the working directory for a local
+// run. The returned function removes the trust entry again.
+func (c *PTYCollector) prepareLocal() (string, func() error, error) {
+	claudePath, err := FindClaude(c.cfg.ClaudePath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := os.MkdirAll(c.cfg.WorkDir, 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+
+	configPath, err := trust.ConfigPath(c.cfg.ConfigDir)
+	if err != nil {
+		return "", nil, err
+	}
+	release, err := trust.New(configPath, c.cfg.LedgerPath).Grant(c.cfg.WorkDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to trust working directory: %w", err)
+	}
+	return claudePath, release, nil
+}
+
+func (c *PTYCollector) launcher(claudePath string) launcher {
+	return launcher{claudePath: claudePath, configDir: c.cfg.ConfigDir, transport: c.cfg.Transport}
+}
+
+// archive stores the raw output of a run along with what it parsed to and,
+// when the capture itself failed, why
+func (c *PTYCollector) archive(output []byte, version, exitStatus string, data *usage.UsageData, parseErr, captureErr error) {
+	if c.cfg.Archive == nil || len(output) == 0 {
+		return
+	}
+
+	meta := archive.Result(data, parseErr)
+	meta.CLIVersion = version
+	meta.ExitStatus = exitStatus
+	if captureErr != nil {
+		meta.CaptureError = captureErr.Error()
+	}
+	if archiveErr := c.cfg.Archive.Add(output, meta); archiveErr != nil {
+		log.Printf("Failed to archive raw output: %v", archiveErr)
+	}
+}
+
+// capture runs claude in a pseudo-terminal and returns its output up to the
+// point where the usage screen was fully rendered, and how claude exited
+func (c *PTYCollector) capture(ctx context.Context, claudePath string) ([]byte, string, error) {
+	cmd := c.launcher(claudePath).command("/usage")
+	if len(c.cfg.Transport) == 0 {
+		cmd.Dir = c.cfg.WorkDir
+	}
+
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: usage.ScreenCols, Rows: usage.ScreenRows})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to start claude: %w", err)
+	}
+	defer ptmx.Close()
+
+	out := newOutputBuffer()
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		buf := make([]byte, 4096)
+		for {
+			n, err := ptmx.Read(buf)
+			if n > 0 {
+				out.Write(buf[:n])
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	waitDone := make(chan error, 1)
+	go func() {
+		waitDone <- cmd.Wait()
+	}()
+
+	// pty.Start makes claude a session leader, so killing its process group
+	// also takes down node and anything else it spawned
+	if err := waitForUsageScreen(ctx, out, readDone); err != nil {
+		killProcessGroup(cmd)
+		<-waitDone
+		if ctx.Err() != nil {
+			return out.Bytes(), exitStatus(cmd), contextError(ctx, out.Bytes())
+		}
+		return out.Bytes(), exitStatus(cmd), err
+	}
+
+	// Everything printed from here on belongs to leaving the usage screen
+	output := out.Bytes()
+
+	// Leave the usage screen, then exit claude
+	ptmx.Write([]byte{0x1b})
+	select {
+	case <-time.After(500 * time.Millisecond):
+	case <-ctx.Done():
+	}
+	ptmx.Write([]byte("exit\r"))
+
+	select {
+	case <-waitDone:
+	case <-time.After(exitGrace):
+		log.Println("claude did not exit in time, killing it")
+		killProcessGroup(cmd)
+		<-waitDone
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-waitDone
+	}
+
+	return output, exitStatus(cmd), nil
+}
+
+// exitStatus describes how a finished command exited
+func exitStatus(cmd *exec.Cmd) string {
+	if cmd.ProcessState == nil {
+		return ""
+	}
+	return cmd.ProcessState.String()
+}
+
+// waitForUsageScreen blocks until the usage screen is rendered and the
+// output has settled, the process stopped writing, a blocking prompt shows
+// up, or ctx is done
+func waitForUsageScreen(ctx context.Context, out *outputBuffer, readDone <-chan struct{}) error {
+	settle := time.NewTimer(settleDelay)
+	settle.Stop()
+	ready := false
+
+	for {
+		select {
+		case <-out.changed:
+			if !ready && out.Contains("Current session") {
+				ready = true
+			}
+			if ready {
+				settle.Reset(settleDelay)
+			} else if err, ok := detectPrompt(out.Contains, errWaitingForInput); ok {
+				// No point waiting for the timeout, nobody will answer it
+				return err
+			}
+		case <-settle.C:
+			return nil
+		case <-readDone:
+			if out.Contains("Current session") {
+				return nil
+			}
+			if err, ok := detectPrompt(out.Contains, usage.ErrSessionNotFound); ok {
+				return err
+			}
+			return usage.ErrSessionNotFound
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// outputBuffer is a concurrency-safe buffer that keeps the raw output along
+// with the screen it renders to, and signals every write
+type outputBuffer struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	term    *screen.Screen
+	changed chan struct{}
+}
+
+func newOutputBuffer() *outputBuffer {
+	return &outputBuffer{
+		term:    screen.New(usage.ScreenCols, usage.ScreenRows),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	n, err := b.buf.Write(p)
+	b.term.Write(p)
+	b.mu.Unlock()
+
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+
+	return n, err
+}
+
+// Contains reports whether text is currently visible on the rendered screen
+func (b *outputBuffer) Contains(text string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.term.Contains(text)
+}
+
+// Bytes returns a copy of everything written so far
+func (b *outputBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
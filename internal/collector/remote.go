@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+const remoteTimeout = 10 * time.Second
+
+// RemoteCollector reads the usage JSON written by another monitor instance,
+// either from a file path (e.g. a shared or synced folder) or an HTTP(S) URL
+type RemoteCollector struct {
+	source string
+	client *http.Client
+}
+
+// NewRemote creates a new RemoteCollector for a file path or URL
+func NewRemote(source string) *RemoteCollector {
+	return &RemoteCollector{
+		source: source,
+		client: &http.Client{
+			Timeout: remoteTimeout,
+		},
+	}
+}
+
+// Collect fetches and decodes the remote usage data
+func (c *RemoteCollector) Collect(ctx context.Context) (*usage.UsageData, error) {
+	if !strings.HasPrefix(c.source, "http://") && !strings.HasPrefix(c.source, "https://") {
+		data, err := os.ReadFile(c.source)
+		if err != nil {
+			return nil, err
+		}
+		return usage.Decode(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "claude-code-monitor")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx, nil)
+		}
+		return nil, fmt.Errorf("failed to fetch usage data: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", c.source, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return usage.Decode(data)
+}
@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/trust"
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+// ScriptCollector runs the legacy claude-code-usage.sh script and reads the
+// JSON file it writes
+type ScriptCollector struct {
+	scriptPath     string
+	outputDir      string
+	ledgerPath     string
+	transientTrust bool
+}
+
+// NewScript creates a new ScriptCollector. The script always writes to
+// ~/.claude-code-monitor, so outputDir must point there. The script's
+// directory is trusted like the pty collector's workspace, recording the
+// edit in the ledger at ledgerPath.
+func NewScript(scriptPath, outputDir, ledgerPath string, transientTrust bool) *ScriptCollector {
+	return &ScriptCollector{
+		scriptPath:     scriptPath,
+		outputDir:      outputDir,
+		ledgerPath:     ledgerPath,
+		transientTrust: transientTrust,
+	}
+}
+
+// Collect runs the script and loads the usage data it produced
+func (c *ScriptCollector) Collect(ctx context.Context) (*usage.UsageData, error) {
+	dir := filepath.Dir(c.scriptPath)
+	configPath, err := trust.ConfigPath("")
+	if err != nil {
+		return nil, err
+	}
+	release, err := trust.New(configPath, c.ledgerPath).Grant(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to trust script directory: %w", err)
+	}
+	if c.transientTrust {
+		defer func() {
+			if err := release(); err != nil {
+				log.Printf("Failed to remove trust for %s: %v", dir, err)
+			}
+		}()
+	}
+
+	cmd := exec.Command("/bin/bash", c.scriptPath)
+	cmd.Dir = dir
+	// The script spawns expect, which spawns claude; all of them must go on timeout
+	setProcessGroup(cmd)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start script: %w", err)
+	}
+
+	waitDone := make(chan error, 1)
+	go func() {
+		waitDone <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-waitDone:
+		if err != nil {
+			return nil, diagnoseScript(output.String(), fmt.Errorf("script execution failed: %w\nOutput: %s", err, output.String()))
+		}
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-waitDone
+		return nil, contextError(ctx, output.Bytes())
+	}
+
+	return usage.Load(filepath.Join(c.outputDir, "claude-code-usage.json"))
+}
+
+// diagnoseScript classifies a script failure from the errors it logged
+func diagnoseScript(output string, err error) error {
+	switch {
+	case strings.Contains(output, "is required but not installed"):
+		e := newError(KindDependencyMissing, err)
+		e.Hint = "Install expect (brew install expect), or switch to the pty collector"
+		return e
+	case strings.Contains(output, "claude CLI not found"):
+		return newError(KindCLINotFound, err)
+	case strings.Contains(output, ".claude.json not found"):
+		e := newError(KindPromptBlocking, err)
+		e.Hint = "Run claude in a terminal once to finish its first-run setup"
+		return e
+	case strings.Contains(output, "'Current session' not found"):
+		return newError(KindSessionNotFound, err)
+	}
+	return err
+}
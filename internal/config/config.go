@@ -2,19 +2,200 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultCollectorTimeout is the collection timeout in seconds
+	defaultCollectorTimeout = 90
+
+	// defaultMinGap is the minimum time between two runs in seconds
+	defaultMinGap = 30
+
+	// defaultArchiveKeep is how many raw captures are archived
+	defaultArchiveKeep = 50
+
+	// defaultRetries is how many times a failed attempt is retried per run
+	defaultRetries = 2
+
+	// defaultBreakerThreshold is how many failed runs in a row pause collection
+	defaultBreakerThreshold = 3
+)
+
+// Trust modes
+const (
+	TrustPersistent = "persistent"
+	TrustTransient  = "transient"
+)
+
+// Collector types
+const (
+	CollectorPTY     = "pty"
+	CollectorScript  = "script"
+	CollectorFixture = "fixture"
+	CollectorRemote  = "remote"
 )
 
 type Config struct {
-	AutoUpdateEnabled bool `json:"auto_update_enabled"`
-	UpdateInterval    int  `json:"update_interval_seconds"`
+	AutoUpdateEnabled bool            `json:"auto_update_enabled"`
+	UpdateInterval    int             `json:"update_interval_seconds"`
+	Collector         CollectorConfig `json:"collector"`
+
+	// Profiles lists the Claude accounts to monitor. When empty, a single
+	// unnamed profile using the collector settings is monitored.
+	Profiles []Profile `json:"profiles,omitempty"`
+
+	// IconProfile is the label of the profile that drives the menu bar icon.
+	// When empty, the icon reflects the profile with the highest session usage.
+	IconProfile string `json:"icon_profile,omitempty"`
+}
+
+// Profile is a Claude account (login) monitored by the app
+type Profile struct {
+	Label string `json:"label"`
+
+	// ClaudeConfigDir is the CLAUDE_CONFIG_DIR of the account, empty for ~/.claude
+	ClaudeConfigDir string `json:"claude_config_dir,omitempty"`
+
+	// ClaudePath overrides the claude binary used for this profile. When
+	// empty, collector.claude_path applies.
+	ClaudePath string `json:"claude_path,omitempty"`
+
+	// Transport overrides collector.transport for this profile
+	Transport string `json:"transport,omitempty"`
+}
+
+var nonSlugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slug returns a file name safe version of the label
+func (p Profile) Slug() string {
+	slug := strings.Trim(nonSlugRe.ReplaceAllString(strings.ToLower(p.Label), "-"), "-")
+	if slug == "" {
+		return "default"
+	}
+	return slug
+}
+
+// Is reports whether the profile has the given label, ignoring case
+func (p Profile) Is(label string) bool {
+	return strings.EqualFold(p.Label, label)
+}
+
+// Dir returns where the profile's data files live. The unnamed profile uses
+// outputDir itself, so single-account setups keep their existing files.
+func (p Profile) Dir(outputDir string) string {
+	if p.Label == "" {
+		return outputDir
+	}
+	return filepath.Join(outputDir, "profiles", p.Slug())
+}
+
+// ActiveProfiles returns the configured profiles, or the unnamed default one
+func (c *Config) ActiveProfiles() []Profile {
+	if len(c.Profiles) == 0 {
+		return []Profile{{
+			ClaudeConfigDir: c.Collector.ClaudeConfigDir,
+			ClaudePath:      c.Collector.ClaudePath,
+			Transport:       c.Collector.Transport,
+		}}
+	}
+
+	profiles := make([]Profile, len(c.Profiles))
+	for i, p := range c.Profiles {
+		if p.ClaudePath == "" {
+			p.ClaudePath = c.Collector.ClaudePath
+		}
+		if p.Transport == "" {
+			p.Transport = c.Collector.Transport
+		}
+		profiles[i] = p
+	}
+	return profiles
+}
+
+// CollectorConfig selects where usage data comes from
+type CollectorConfig struct {
+	// Type is one of "pty" (default), "script", "fixture" or "remote"
+	Type string `json:"type"`
+
+	// ClaudePath is the claude CLI to run. When empty, the usual install
+	// locations are searched on every run.
+	ClaudePath string `json:"claude_path,omitempty"`
+
+	// Transport is a command prefix the "pty" collector runs claude through,
+	// e.g. "ssh -t buildbox" or "docker exec -it devbox"
+	Transport string `json:"transport,omitempty"`
+
+	// ScriptPath overrides the location of claude-code-usage.sh for "script"
+	ScriptPath string `json:"script_path,omitempty"`
+
+	// FixturePath is the raw /usage capture replayed by "fixture"
+	FixturePath string `json:"fixture_path,omitempty"`
+
+	// Source is the JSON file path or HTTP(S) URL read by "remote"
+	Source string `json:"source,omitempty"`
+
+	// TimeoutSeconds limits how long a single collection run may take
+	TimeoutSeconds int `json:"timeout_seconds"`
+
+	// ClaudeConfigDir runs claude with an isolated CLAUDE_CONFIG_DIR for "pty"
+	ClaudeConfigDir string `json:"claude_config_dir,omitempty"`
+
+	// TrustMode is "persistent" (default) to trust the collector's working
+	// directory once, leaving a single entry in ~/.claude.json, or
+	// "transient" to remove the trust entry after every run
+	TrustMode string `json:"trust_mode,omitempty"`
+
+	// MinGapSeconds is the minimum time between the end of a run and the
+	// start of the next one
+	MinGapSeconds int `json:"min_gap_seconds"`
+
+	// ArchiveKeep is how many raw "pty" captures are kept compressed for
+	// debugging and replay, 0 disables the archive
+	ArchiveKeep int `json:"archive_keep"`
+
+	// Retries is how many times a failed attempt is retried, with backoff,
+	// before the run gives up. 0 disables retries.
+	Retries int `json:"retries"`
+
+	// BreakerThreshold is how many failed runs in a row make the monitor
+	// back off from polling until a run succeeds. 0 disables the breaker.
+	BreakerThreshold int `json:"breaker_threshold"`
+}
+
+// Timeout returns the collection timeout, falling back to the default
+func (c CollectorConfig) Timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return defaultCollectorTimeout * time.Second
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// MinGap returns the minimum time between two runs
+func (c CollectorConfig) MinGap() time.Duration {
+	if c.MinGapSeconds < 0 {
+		return 0
+	}
+	return time.Duration(c.MinGapSeconds) * time.Second
 }
 
 func DefaultConfig() *Config {
 	return &Config{
 		AutoUpdateEnabled: false,
 		UpdateInterval:    1800,
+		Collector: CollectorConfig{
+			Type:             CollectorPTY,
+			TimeoutSeconds:   defaultCollectorTimeout,
+			MinGapSeconds:    defaultMinGap,
+			ArchiveKeep:      defaultArchiveKeep,
+			Retries:          defaultRetries,
+			BreakerThreshold: defaultBreakerThreshold,
+		},
 	}
 }
 
@@ -34,12 +215,44 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	// Start from defaults so settings added in newer versions get sane values
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
 		return DefaultConfig(), err
 	}
+	if err := cfg.validate(); err != nil {
+		return DefaultConfig(), fmt.Errorf("invalid %s: %w", configPath, err)
+	}
+
+	return cfg, nil
+}
+
+// validate rejects settings that can't work together
+func (c *Config) validate() error {
+	// Only the pty collector runs claude per profile. The others read a
+	// single script output, fixture or source, which would give every
+	// profile the same data.
+	if len(c.Profiles) > 1 && c.Collector.Type != CollectorPTY && c.Collector.Type != "" {
+		return fmt.Errorf("collector type %q reads a single source and can't monitor %d profiles, use the %q collector or remove \"profiles\"",
+			c.Collector.Type, len(c.Profiles), CollectorPTY)
+	}
 
-	return &cfg, nil
+	// Every profile keeps its files in the directory named after its slug,
+	// so two labels with the same slug would share them
+	if len(c.Profiles) > 1 {
+		slugs := make(map[string]string)
+		for _, p := range c.Profiles {
+			if strings.TrimSpace(p.Label) == "" {
+				return fmt.Errorf("every profile needs a label when there are several")
+			}
+			if other, ok := slugs[p.Slug()]; ok {
+				return fmt.Errorf("profiles %q and %q would share the data directory profiles/%s, give them distinct labels",
+					other, p.Label, p.Slug())
+			}
+			slugs[p.Slug()] = p.Label
+		}
+	}
+	return nil
 }
 
 func SaveConfig(cfg *Config) error {
@@ -61,3 +274,47 @@ func SaveConfig(cfg *Config) error {
 
 	return os.WriteFile(configPath, data, 0644)
 }
+
+// SplitCommand splits a command line into arguments at unquoted whitespace.
+// Single and double quotes group words, and a backslash escapes the next
+// character outside single quotes. An unterminated quote runs to the end.
+func SplitCommand(s string) []string {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
@@ -0,0 +1,116 @@
+package coordinator
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Coordinator makes sure only one collection runs at a time. Concurrent
+// triggers (scheduler, "Update Now", ...) join the run already in flight
+// instead of starting another one.
+type Coordinator struct {
+	ctx    context.Context
+	run    func(ctx context.Context) error
+	minGap time.Duration
+
+	mu           sync.Mutex
+	current      *call
+	lastFinished time.Time
+	lastErr      error
+
+	// Callback when a run starts or finishes
+	OnStateChange func(inFlight bool)
+}
+
+// call is a single run that any number of callers can wait on
+type call struct {
+	done chan struct{}
+	err  error
+}
+
+// New creates a new Coordinator. Runs execute with ctx, so cancelling it
+// aborts the run in flight. A trigger less than minGap after the previous
+// run finished returns that run's result instead of starting a new one.
+func New(ctx context.Context, run func(ctx context.Context) error, minGap time.Duration) *Coordinator {
+	return &Coordinator{
+		ctx:    ctx,
+		run:    run,
+		minGap: minGap,
+	}
+}
+
+// Run starts a run, or joins the one in flight, and waits for its result.
+// ctx only bounds the wait; the run itself keeps going for other callers.
+func (c *Coordinator) Run(ctx context.Context) error {
+	return c.start(ctx, false)
+}
+
+// RunNow is Run without the min gap, for triggers the user asked for
+func (c *Coordinator) RunNow(ctx context.Context) error {
+	return c.start(ctx, true)
+}
+
+func (c *Coordinator) start(ctx context.Context, force bool) error {
+	c.mu.Lock()
+	cl := c.current
+	if cl == nil {
+		if !force && !c.lastFinished.IsZero() && time.Since(c.lastFinished) < c.minGap {
+			err := c.lastErr
+			c.mu.Unlock()
+			log.Printf("Skipping run, last one finished %s ago", time.Since(c.lastFinished).Round(time.Second))
+			return err
+		}
+
+		cl = &call{done: make(chan struct{})}
+		c.current = cl
+		c.mu.Unlock()
+
+		c.notify(true)
+		go c.execute(cl)
+	} else {
+		c.mu.Unlock()
+		log.Println("Joining run already in flight")
+	}
+
+	select {
+	case <-cl.done:
+		return cl.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// InFlight reports whether a run is currently executing
+func (c *Coordinator) InFlight() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current != nil
+}
+
+// LastFinished returns when the last run finished, or the zero time
+func (c *Coordinator) LastFinished() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastFinished
+}
+
+func (c *Coordinator) execute(cl *call) {
+	cl.err = c.run(c.ctx)
+
+	c.mu.Lock()
+	c.current = nil
+	c.lastFinished = time.Now()
+	c.lastErr = cl.err
+	c.mu.Unlock()
+
+	close(cl.done)
+	c.notify(false)
+}
+
+func (c *Coordinator) notify(inFlight bool) {
+	if c.OnStateChange != nil {
+		c.OnStateChange(inFlight)
+	}
+}
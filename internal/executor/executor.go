@@ -1,53 +1,220 @@
 package executor
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/breaker"
+	"github.com/ribeirogab/claude-code-monitor/internal/collector"
+	"github.com/ribeirogab/claude-code-monitor/internal/history"
+	"github.com/ribeirogab/claude-code-monitor/internal/status"
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+const (
+	// retryDelay is the wait before the first retry, doubled for every next one
+	retryDelay = 10 * time.Second
+
+	// maxRetryDelay caps the wait between two attempts
+	maxRetryDelay = 2 * time.Minute
 )
 
-// Executor handles the execution of the claude-code-usage.sh script
+// ErrCircuitOpen is returned when a run is skipped because of earlier failures
+var ErrCircuitOpen = errors.New("skipped after repeated failures")
+
+// Config configures an Executor
+type Config struct {
+	// Timeout cancels a single attempt
+	Timeout time.Duration
+	// Retries is how many times a failed attempt is retried within a run
+	Retries int
+	// Breaker slows down runs after repeated failed runs
+	Breaker breaker.Config
+}
+
+// Executor runs a usage collection and stores the result in the output directory
 type Executor struct {
-	scriptPath string
-	outputDir  string
+	collector collector.Collector
+	outputDir string
+	timeout   time.Duration
+	retries   int
+	breaker   *breaker.Breaker
+	history   *history.Store
+	windows   *history.Tracker
+
+	// lastSnapshot is the time of the last snapshot added to the history, so
+	// a remote source that hasn't updated isn't recorded twice
+	lastSnapshot time.Time
 }
 
 // New creates a new Executor instance
-func New(scriptPath, outputDir string) *Executor {
-	return &Executor{
-		scriptPath: scriptPath,
-		outputDir:  outputDir,
+func New(c collector.Collector, outputDir string, cfg Config) *Executor {
+	e := &Executor{
+		collector: c,
+		outputDir: outputDir,
+		timeout:   cfg.Timeout,
+		retries:   cfg.Retries,
+		breaker:   breaker.New(cfg.Breaker),
+		history:   history.New(filepath.Join(outputDir, history.DirName)),
+	}
+
+	// Pick up where the previous process stopped, so a remote source that
+	// hasn't updated since isn't recorded again after a restart
+	last, err := e.history.Last()
+	if err != nil {
+		log.Printf("Failed to read usage history: %v", err)
+	}
+	e.lastSnapshot = last
+
+	e.windows, err = history.NewTracker(e.history)
+	if err != nil {
+		log.Printf("Failed to resume usage windows: %v", err)
 	}
+
+	return e
+}
+
+// Breaker returns the circuit breaker guarding the runs
+func (e *Executor) Breaker() *breaker.Breaker {
+	return e.breaker
 }
 
-// Execute runs the script and handles output
-func (e *Executor) Execute() error {
+// Execute collects usage data and writes it to claude-code-usage.json.
+// Attempts that fail for a reason that may go away on its own are retried
+// with backoff, each one aborted when the timeout expires; the whole run is
+// aborted when ctx is cancelled. After repeated failed runs the breaker
+// opens and runs return ErrCircuitOpen until its cooldown is over.
+// Collection failures are returned as a *collector.Error, and the outcome of
+// the run is recorded in the status sidecar.
+func (e *Executor) Execute(ctx context.Context) error {
+	if snapshot := e.breaker.Snapshot(); snapshot.State == breaker.Open {
+		return fmt.Errorf("%w, next attempt at %s", ErrCircuitOpen, snapshot.RetryAt.Format("15:04:05"))
+	}
+
 	if err := e.ensureOutputDir(); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	if err := e.runScript(); err != nil {
-		return fmt.Errorf("failed to run script: %w", err)
+	statusPath := filepath.Join(e.outputDir, status.FileName)
+	start := time.Now()
+	attempts, err := e.collectWithRetries(ctx)
+	if errors.Is(err, context.Canceled) {
+		// Aborted by the app, says nothing about the collector
+		return err
 	}
 
-	return nil
+	if err == nil {
+		e.breaker.Success()
+	} else {
+		e.breaker.Failure()
+	}
+
+	st := &status.Status{
+		LastAttempt:         start.UTC(),
+		DurationSeconds:     time.Since(start).Round(time.Millisecond).Seconds(),
+		Attempts:            attempts,
+		ConsecutiveFailures: e.breaker.Snapshot().Failures,
+	}
+	if err == nil {
+		st.LastSuccess = &st.LastAttempt
+	} else if previous, loadErr := status.Load(statusPath); loadErr == nil {
+		// The usage JSON still holds the data of the last success
+		st.LastSuccess = previous.LastSuccess
+	}
+
+	if err != nil {
+		classified := collector.Classify(err)
+		st.LastError = &status.Error{
+			Kind:    string(classified.Kind),
+			Summary: classified.Kind.Summary(),
+			Message: classified.Error(),
+			Hint:    classified.Hint,
+		}
+		err = fmt.Errorf("failed to collect usage: %w", classified)
+	}
+
+	if saveErr := status.Save(statusPath, st); saveErr != nil {
+		log.Printf("Failed to save status: %v", saveErr)
+	}
+
+	return err
 }
 
-// ensureOutputDir creates the output directory if it doesn't exist
-func (e *Executor) ensureOutputDir() error {
-	return os.MkdirAll(e.outputDir, 0755)
+// collectWithRetries calls collect until it succeeds, fails for good or runs
+// out of retries, and returns how many attempts were made
+func (e *Executor) collectWithRetries(ctx context.Context) (int, error) {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err := e.collect(ctx)
+		if err == nil || errors.Is(err, context.Canceled) || attempt > e.retries {
+			return attempt, err
+		}
+		if kind := collector.Classify(err).Kind; !kind.Retryable() {
+			return attempt, err
+		}
+
+		log.Printf("Attempt %d failed, retrying in %s: %v", attempt, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
 }
 
-// runScript executes the claude-code-usage.sh script
-func (e *Executor) runScript() error {
-	cmd := exec.Command("/bin/bash", e.scriptPath)
-	cmd.Dir = filepath.Dir(e.scriptPath)
+// collect runs the collector and saves its data
+func (e *Executor) collect(ctx context.Context) error {
+	ctx, cancel := collector.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	data, err := e.collector.Collect(ctx)
+	if err != nil {
+		var timeoutErr *collector.TimeoutError
+		if errors.As(err, &timeoutErr) {
+			log.Printf("Collection timed out, last screen:\n%s", timeoutErr.Screen())
+		}
+		return err
+	}
+
+	if err := usage.Save(filepath.Join(e.outputDir, "claude-code-usage.json"), data); err != nil {
+		return fmt.Errorf("failed to save usage data: %w", err)
+	}
+
+	// The run succeeded even if the history can't be written
+	if err := e.record(data); err != nil {
+		log.Printf("Failed to record usage history: %v", err)
+	}
 
-	output, err := cmd.CombinedOutput()
+	return nil
+}
+
+// record appends the data to the usage history and follows its windows
+func (e *Executor) record(data *usage.UsageData) error {
+	snapshot, err := history.SnapshotOf(data)
 	if err != nil {
-		return fmt.Errorf("script execution failed: %w\nOutput: %s", err, string(output))
+		return err
+	}
+	if !snapshot.Time.After(e.lastSnapshot) {
+		return nil
+	}
+	if err := e.history.Append(snapshot); err != nil {
+		return err
 	}
+	e.lastSnapshot = snapshot.Time
 
+	if err := e.windows.Add(snapshot); err != nil {
+		return fmt.Errorf("failed to record usage windows: %w", err)
+	}
 	return nil
 }
+
+// ensureOutputDir creates the output directory if it doesn't exist
+func (e *Executor) ensureOutputDir() error {
+	return os.MkdirAll(e.outputDir, 0755)
+}
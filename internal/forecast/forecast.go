@@ -0,0 +1,152 @@
+package forecast
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/history"
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+// WeekLength is the length of the window of weekly limits
+const WeekLength = 7 * 24 * time.Hour
+
+const (
+	// sessionWindow is how far back the session burn rate looks. Sessions
+	// last 5 hours, so older points say little about the current pace.
+	sessionWindow = 2 * time.Hour
+
+	// weekWindow is how far back the burn rate of longer limits looks
+	weekWindow = 24 * time.Hour
+
+	// MaxWindow is the longest window of any metric, how much history a
+	// forecast needs
+	MaxWindow = weekWindow
+
+	// minSpan is the shortest stretch of points a rate is computed from
+	minSpan = 10 * time.Minute
+)
+
+// Forecast is the burn rate of a limit and where it leads
+type Forecast struct {
+	Metric string
+	// Time and Percent are the latest point of the metric
+	Time    time.Time
+	Percent int
+	// RatePerHour is how many percent the limit grew per hour over the window
+	RatePerHour float64
+	// LimitAt is when the limit reaches 100% at this pace, nil while it
+	// isn't growing
+	LimitAt *time.Time
+	// ResetsAt is when the limit resets, nil if unknown
+	ResetsAt *time.Time
+}
+
+// Window returns the sliding window the burn rate of metric is measured over
+func Window(metric string) time.Duration {
+	if metric == usage.SessionLimitID {
+		return sessionWindow
+	}
+	if strings.HasPrefix(metric, "week") {
+		return weekWindow
+	}
+	return sessionWindow
+}
+
+// HitsLimit reports whether the limit reaches 100% before it resets
+func (f Forecast) HitsLimit() bool {
+	if f.LimitAt == nil {
+		return false
+	}
+	return f.ResetsAt == nil || f.LimitAt.Before(*f.ResetsAt)
+}
+
+// AtReset returns the percentage the limit is projected to reach when it
+// resets, false when the reset time is unknown
+func (f Forecast) AtReset() (float64, bool) {
+	if f.ResetsAt == nil {
+		return 0, false
+	}
+	hours := f.ResetsAt.Sub(f.Time).Hours()
+	return min(100, float64(f.Percent)+f.RatePerHour*max(0, hours)), true
+}
+
+// Burn computes the forecast of a metric from its history points, oldest
+// first. Only the points within the metric's window before the last one and
+// after the last reset are used. It returns false when there aren't enough
+// of them to tell a pace.
+func Burn(metric string, points []history.Point) (Forecast, bool) {
+	var own []history.Point
+	for _, p := range points {
+		if p.Metric == metric {
+			own = append(own, p)
+		}
+	}
+	if len(own) < 2 {
+		return Forecast{}, false
+	}
+
+	last := own[len(own)-1]
+	from := last.Time.Add(-Window(metric))
+	first := len(own) - 1
+	for first > 0 {
+		prev := own[first-1]
+		if prev.Time.Before(from) {
+			break
+		}
+		if _, reset := history.ResetBetween(prev.Sample, own[first].Sample); reset {
+			break
+		}
+		first--
+	}
+
+	start := own[first]
+	span := last.Time.Sub(start.Time)
+	if span < minSpan {
+		return Forecast{}, false
+	}
+
+	f := Forecast{
+		Metric:      metric,
+		Time:        last.Time,
+		Percent:     last.Percent,
+		RatePerHour: float64(last.Percent-start.Percent) / span.Hours(),
+		ResetsAt:    last.ResetsAt,
+	}
+	if f.RatePerHour > 0 {
+		remaining := time.Duration(float64(100-last.Percent) / f.RatePerHour * float64(time.Hour))
+		limitAt := last.Time.Add(max(0, remaining))
+		f.LimitAt = &limitAt
+	}
+	return f, true
+}
+
+// Pace compares a weekly limit with an even spread of its budget over the week
+type Pace struct {
+	// Used is the percentage of the limit used
+	Used float64
+	// Elapsed is the percentage of the week gone by
+	Elapsed float64
+}
+
+// Ahead returns how many points the limit is ahead of an even pace, negative
+// when it's under
+func (p Pace) Ahead() float64 {
+	return p.Used - p.Elapsed
+}
+
+// PaceOf returns the pace of a weekly limit at time at. The week is taken to
+// start WeekLength before the limit resets. It returns false for other limits
+// and when the reset time is unknown.
+func PaceOf(limit usage.Limit, at time.Time) (Pace, bool) {
+	if !strings.HasPrefix(limit.ID, "week") || limit.ResetsAt == nil {
+		return Pace{}, false
+	}
+
+	start := limit.ResetsAt.Add(-WeekLength)
+	elapsed := at.Sub(start).Seconds() / WeekLength.Seconds() * 100
+	return Pace{
+		Used:    float64(limit.Percent),
+		Elapsed: min(100, max(0, elapsed)),
+	}, true
+}
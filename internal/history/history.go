@@ -0,0 +1,259 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/fsutil"
+	"github.com/ribeirogab/claude-code-monitor/internal/usage"
+)
+
+// DirName is the history directory inside a profile's data directory
+const DirName = "history"
+
+const (
+	// segmentLayout names the file holding a month of snapshots, e.g. 2025-11.jsonl
+	segmentLayout = "2006-01"
+	segmentExt    = ".jsonl"
+
+	lockTimeout = 5 * time.Second
+)
+
+// Sample is the value of one limit in a snapshot
+type Sample struct {
+	Metric   string     `json:"metric"`
+	Percent  int        `json:"percent"`
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+}
+
+// Snapshot is the usage data of a successful run
+type Snapshot struct {
+	Time    time.Time `json:"time"`
+	Samples []Sample  `json:"samples"`
+}
+
+// Point is a single sample with its time, as returned by queries
+type Point struct {
+	Time time.Time `json:"time"`
+	Sample
+}
+
+// Query selects points from the store. Zero times leave the range open, and
+// an empty Metrics matches every metric.
+type Query struct {
+	From    time.Time // inclusive
+	To      time.Time // exclusive
+	Metrics []string
+}
+
+// matches reports whether a point at t for metric falls in the query
+func (q Query) matches(t time.Time, metric string) bool {
+	if !q.From.IsZero() && t.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !t.Before(q.To) {
+		return false
+	}
+	return len(q.Metrics) == 0 || slices.Contains(q.Metrics, metric)
+}
+
+// SnapshotOf turns usage data into a snapshot taken at its timestamp
+func SnapshotOf(data *usage.UsageData) (Snapshot, error) {
+	t, err := time.Parse(time.RFC3339, data.Timestamp)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("invalid timestamp %q: %w", data.Timestamp, err)
+	}
+
+	snapshot := Snapshot{Time: t.UTC()}
+	for _, l := range data.Limits {
+		snapshot.Samples = append(snapshot.Samples, Sample{Metric: l.ID, Percent: l.Percent, ResetsAt: l.ResetsAt})
+	}
+	return snapshot, nil
+}
+
+// Store is an append-only history of usage snapshots, split into one JSONL
+// file per month so queries only read the months they cover
+type Store struct {
+	dir string
+}
+
+// New creates a Store backed by the files in dir
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Append records a snapshot in the file of its month
+func (s *Store) Append(snapshot Snapshot) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	unlock, err := fsutil.Lock(filepath.Join(s.dir, ".lock"), lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	line, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f, err := os.OpenFile(s.segment(snapshot.Time), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(line)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Query returns the points matching q, oldest first
+func (s *Store) Query(q Query) ([]Point, error) {
+	var points []Point
+	err := s.Each(q, func(p Point) error {
+		points = append(points, p)
+		return nil
+	})
+	return points, err
+}
+
+// Each calls fn for every point matching q, oldest first, without loading
+// more than a month at a time. An error from fn stops the walk and is returned.
+func (s *Store) Each(q Query, fn func(Point) error) error {
+	months, err := s.months()
+	if err != nil {
+		return err
+	}
+
+	for _, month := range months {
+		// Skip months entirely outside the range
+		if !q.To.IsZero() && !month.Before(q.To) {
+			continue
+		}
+		if !q.From.IsZero() && !month.AddDate(0, 1, 0).After(q.From) {
+			continue
+		}
+
+		snapshots, err := s.readSegment(s.segment(month))
+		if err != nil {
+			return err
+		}
+		for _, snapshot := range snapshots {
+			for _, sample := range snapshot.Samples {
+				if !q.matches(snapshot.Time, sample.Metric) {
+					continue
+				}
+				if err := fn(Point{Time: snapshot.Time, Sample: sample}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Last returns the time of the newest snapshot, or the zero time when there's none
+func (s *Store) Last() (time.Time, error) {
+	months, err := s.months()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for i := len(months) - 1; i >= 0; i-- {
+		snapshots, err := s.readSegment(s.segment(months[i]))
+		if err != nil {
+			return time.Time{}, err
+		}
+		if len(snapshots) > 0 {
+			return snapshots[len(snapshots)-1].Time, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+// Metrics returns every metric recorded in the range of q, sorted
+func (s *Store) Metrics(q Query) ([]string, error) {
+	seen := make(map[string]bool)
+	err := s.Each(Query{From: q.From, To: q.To}, func(p Point) error {
+		seen[p.Metric] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]string, 0, len(seen))
+	for metric := range seen {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+	return metrics, nil
+}
+
+// segment returns the file holding the snapshots of t's month
+func (s *Store) segment(t time.Time) string {
+	return filepath.Join(s.dir, t.UTC().Format(segmentLayout)+segmentExt)
+}
+
+// months returns the first instant of every month with a file, oldest first
+func (s *Store) months() ([]time.Time, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var months []time.Time
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentExt)
+		if !ok {
+			continue
+		}
+		month, err := time.Parse(segmentLayout, name)
+		if err != nil {
+			continue
+		}
+		months = append(months, month)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
+	return months, nil
+}
+
+// readSegment reads the snapshots of a month file, oldest first
+func (s *Store) readSegment(path string) ([]Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var snapshots []Snapshot
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var snapshot Snapshot
+		// Skip lines cut short by a crash
+		if json.Unmarshal(scanner.Bytes(), &snapshot) != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Time.Before(snapshots[j].Time) })
+	return snapshots, nil
+}
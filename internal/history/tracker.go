@@ -0,0 +1,329 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/fsutil"
+)
+
+const (
+	// windowsFile holds every closed window, one JSON line each
+	windowsFile = "windows.jsonl"
+
+	// windowStateFile holds the current window of every metric, so tracking
+	// resumes after a restart without replaying the whole history
+	windowStateFile = "windows-state.json"
+)
+
+// windowRecord is how a window is written to the windows and state files
+type windowRecord struct {
+	Metric         string     `json:"metric"`
+	Start          time.Time  `json:"start"`
+	End            *time.Time `json:"end,omitempty"`
+	ResetsAt       *time.Time `json:"resets_at,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+	Points         int        `json:"points"`
+	Peak           int        `json:"peak"`
+	HighSeconds    float64    `json:"high_seconds"`
+	AtLimitSeconds float64    `json:"at_limit_seconds"`
+	HitAt          *time.Time `json:"hit_at,omitempty"`
+}
+
+func recordOf(w Window) windowRecord {
+	return windowRecord{
+		Metric:         w.Metric,
+		Start:          w.Start.UTC(),
+		End:            w.End,
+		ResetsAt:       w.ResetsAt,
+		Reason:         w.Reason,
+		Points:         w.Points,
+		Peak:           w.Peak,
+		HighSeconds:    w.High.Seconds(),
+		AtLimitSeconds: w.AtLimit.Seconds(),
+		HitAt:          w.HitAt,
+	}
+}
+
+func (r windowRecord) window() Window {
+	return Window{
+		Metric:   r.Metric,
+		Start:    r.Start,
+		End:      r.End,
+		ResetsAt: r.ResetsAt,
+		Reason:   r.Reason,
+		Points:   r.Points,
+		Peak:     r.Peak,
+		High:     time.Duration(r.HighSeconds * float64(time.Second)),
+		AtLimit:  time.Duration(r.AtLimitSeconds * float64(time.Second)),
+		HitAt:    r.HitAt,
+	}
+}
+
+// windowState is the content of the state file
+type windowState struct {
+	// Last is the time of the last snapshot tracked
+	Last    time.Time               `json:"last"`
+	Metrics map[string]builderState `json:"metrics"`
+}
+
+// builderState is a saved windowBuilder
+type builderState struct {
+	Current windowRecord `json:"current"`
+	Prev    Point        `json:"prev"`
+}
+
+// Tracker follows the current window of every metric as snapshots are
+// recorded, and appends each window to the windows file once it closes
+type Tracker struct {
+	store    *Store
+	builders map[string]*windowBuilder
+	last     time.Time
+}
+
+// NewTracker resumes tracking where the store left off: windows that closed
+// since the last snapshot tracked are recorded now, and the current ones
+// are restored. The first time, the whole history is replayed. On error
+// the tracker starts from scratch.
+func NewTracker(s *Store) (*Tracker, error) {
+	t := &Tracker{store: s, builders: make(map[string]*windowBuilder)}
+
+	builders, closed, last, err := s.resume()
+	if err != nil {
+		return t, err
+	}
+	if err := s.appendWindows(closed); err != nil {
+		return t, err
+	}
+	t.builders, t.last = builders, last
+	return t, s.saveWindowState(builders, last)
+}
+
+// Add follows a snapshot just appended to the store, recording the windows
+// it closes
+func (t *Tracker) Add(snapshot Snapshot) error {
+	if !snapshot.Time.After(t.last) {
+		return nil
+	}
+
+	var closed []Window
+	for _, sample := range snapshot.Samples {
+		b, ok := t.builders[sample.Metric]
+		if !ok {
+			b = &windowBuilder{metric: sample.Metric}
+			t.builders[sample.Metric] = b
+		}
+		if w := b.add(Point{Time: snapshot.Time, Sample: sample}); w != nil {
+			closed = append(closed, *w)
+		}
+	}
+	t.last = snapshot.Time
+
+	if err := t.store.appendWindows(closed); err != nil {
+		return err
+	}
+	return t.store.saveWindowState(t.builders, t.last)
+}
+
+// Windows returns the windows overlapping the range of q, for the metrics
+// it selects, sorted by metric and start. Closed windows are read whole
+// from the windows file, even when they started before q.From, and the
+// current ones from the tracker's state. It never writes.
+func (s *Store) Windows(q Query) ([]Window, error) {
+	recorded, err := s.recordedWindows()
+	if err != nil {
+		return nil, err
+	}
+	builders, closed, _, err := s.resume()
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(recorded, closed...)
+	for _, b := range builders {
+		if b.current != nil {
+			all = append(all, *b.current)
+		}
+	}
+
+	var windows []Window
+	for _, w := range all {
+		if len(q.Metrics) > 0 && !slices.Contains(q.Metrics, w.Metric) {
+			continue
+		}
+		if !q.To.IsZero() && !w.Start.Before(q.To) {
+			continue
+		}
+		if !q.From.IsZero() && w.End != nil && !w.End.After(q.From) {
+			continue
+		}
+		windows = append(windows, w)
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		if windows[i].Metric != windows[j].Metric {
+			return windows[i].Metric < windows[j].Metric
+		}
+		return windows[i].Start.Before(windows[j].Start)
+	})
+	return windows, nil
+}
+
+// resume restores the saved builders and replays the points recorded after
+// them. It returns the builders, the windows that closed in the replay and
+// the time of the last point.
+func (s *Store) resume() (map[string]*windowBuilder, []Window, time.Time, error) {
+	state, err := s.loadWindowState()
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+
+	builders := make(map[string]*windowBuilder)
+	for metric, bs := range state.Metrics {
+		current := bs.Current.window()
+		builders[metric] = &windowBuilder{metric: metric, current: &current, prev: bs.Prev}
+	}
+
+	// Without a state, windows already in the file mustn't be recorded twice
+	ends := make(map[string]time.Time)
+	if state.Last.IsZero() {
+		recorded, err := s.recordedWindows()
+		if err != nil {
+			return nil, nil, time.Time{}, err
+		}
+		for _, w := range recorded {
+			if w.End.After(ends[w.Metric]) {
+				ends[w.Metric] = *w.End
+			}
+		}
+	}
+
+	var closed []Window
+	last := state.Last
+	err = s.Each(Query{From: state.Last}, func(p Point) error {
+		if !p.Time.After(state.Last) {
+			return nil
+		}
+		end, recorded := ends[p.Metric]
+		if recorded && p.Time.Before(end) {
+			return nil
+		}
+		b, ok := builders[p.Metric]
+		if !ok {
+			b = &windowBuilder{metric: p.Metric}
+			if recorded {
+				b.start = &end
+			}
+			builders[p.Metric] = b
+		}
+		if w := b.add(p); w != nil {
+			closed = append(closed, *w)
+		}
+		if p.Time.After(last) {
+			last = p.Time
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	return builders, closed, last, nil
+}
+
+func (s *Store) loadWindowState() (windowState, error) {
+	var state windowState
+	data, err := os.ReadFile(filepath.Join(s.dir, windowStateFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, err
+	}
+	// A broken state is rebuilt from the history
+	if json.Unmarshal(data, &state) != nil {
+		return windowState{}, nil
+	}
+	return state, nil
+}
+
+func (s *Store) saveWindowState(builders map[string]*windowBuilder, last time.Time) error {
+	state := windowState{Last: last, Metrics: make(map[string]builderState)}
+	for metric, b := range builders {
+		if b.current == nil {
+			continue
+		}
+		state.Metrics[metric] = builderState{Current: recordOf(*b.current), Prev: b.prev}
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(filepath.Join(s.dir, windowStateFile), data, 0644)
+}
+
+// recordedWindows reads the windows file, oldest first
+func (s *Store) recordedWindows() ([]Window, error) {
+	f, err := os.Open(filepath.Join(s.dir, windowsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var windows []Window
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r windowRecord
+		// Skip lines cut short by a crash
+		if json.Unmarshal(scanner.Bytes(), &r) != nil || r.End == nil {
+			continue
+		}
+		windows = append(windows, r.window())
+	}
+	return windows, scanner.Err()
+}
+
+// appendWindows records closed windows in the windows file
+func (s *Store) appendWindows(windows []Window) error {
+	if len(windows) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	unlock, err := fsutil.Lock(filepath.Join(s.dir, ".lock"), lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var lines []byte
+	for _, w := range windows {
+		line, err := json.Marshal(recordOf(w))
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, windowsFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(lines)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerRecordsWindows(t *testing.T) {
+	s := New(t.TempDir())
+	base := time.Date(2025, 11, 20, 8, 0, 0, 0, time.UTC)
+	firstReset := base.Add(5 * time.Hour)
+	secondReset := base.Add(10 * time.Hour)
+
+	snapshot := func(hours float64, percent int, resetsAt time.Time) Snapshot {
+		return Snapshot{
+			Time:    base.Add(time.Duration(hours * float64(time.Hour))),
+			Samples: []Sample{{Metric: "session", Percent: percent, ResetsAt: &resetsAt}},
+		}
+	}
+	add := func(tr *Tracker, snapshots ...Snapshot) {
+		t.Helper()
+		for _, sn := range snapshots {
+			if err := s.Append(sn); err != nil {
+				t.Fatal(err)
+			}
+			if tr == nil {
+				continue
+			}
+			if err := tr.Add(sn); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// History recorded before windows were tracked is replayed once
+	add(nil, snapshot(0, 10, firstReset), snapshot(1, 85, firstReset))
+	tr, err := NewTracker(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	add(tr, snapshot(2, 100, firstReset), snapshot(3, 100, firstReset), snapshot(6, 5, secondReset))
+
+	// A restart picks up the current window and the snapshots it missed
+	add(nil, snapshot(7, 20, secondReset))
+	tr, err = NewTracker(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	add(tr, snapshot(11, 1, secondReset.Add(5*time.Hour)))
+
+	recorded, err := s.recordedWindows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 2 {
+		t.Fatalf("got %d recorded windows, want 2: %+v", len(recorded), recorded)
+	}
+
+	first := recorded[0]
+	if !first.Start.Equal(base) || !first.End.Equal(firstReset) || first.Reason != ResetPercentDrop {
+		t.Errorf("first window: %v to %v (%s)", first.Start, first.End, first.Reason)
+	}
+	if first.Peak != 100 || first.Points != 4 || first.High != 4*time.Hour || first.AtLimit != 3*time.Hour {
+		t.Errorf("first window summary: %+v", first)
+	}
+	if first.HitAt == nil || !first.HitAt.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("first window hit at %v", first.HitAt)
+	}
+
+	second := recorded[1]
+	if !second.Start.Equal(firstReset) || !second.End.Equal(secondReset) || second.Points != 2 || second.Peak != 20 {
+		t.Errorf("second window: %+v", second)
+	}
+
+	// Windows that started before the range are returned whole
+	windows, err := s.Windows(Query{From: base.Add(2 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 3 {
+		t.Fatalf("got %d windows, want 3: %+v", len(windows), windows)
+	}
+	if !windows[0].Start.Equal(base) || windows[0].Peak != 100 {
+		t.Errorf("first window in range: %+v", windows[0])
+	}
+	if current := windows[2]; current.End != nil || !current.Start.Equal(secondReset) || current.Points != 1 {
+		t.Errorf("current window: %+v", current)
+	}
+}
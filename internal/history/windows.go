@@ -0,0 +1,138 @@
+package history
+
+import (
+	"time"
+)
+
+const (
+	// HighPercent is the usage above which a window counts as running high
+	HighPercent = 80
+
+	// resetTolerance is how far the reset time of a limit may move before it
+	// counts as a new window. Reset times are parsed from text like "10pm",
+	// so they can shift a little between runs of the same window.
+	resetTolerance = time.Hour
+
+	// maxGap caps how long a point is taken to last, so time the monitor
+	// wasn't running isn't counted as spent at its last percentage
+	maxGap = 2 * time.Hour
+)
+
+// How the end of a window was detected
+const (
+	ResetPercentDrop = "percent_drop"
+	ResetTimeChanged = "reset_changed"
+)
+
+// Window is a usage window of a limit, from one reset to the next, with a
+// summary of the points recorded in it. Resets are detected as snapshots
+// are recorded, by a Tracker.
+type Window struct {
+	Metric string
+	Start  time.Time
+	// End is when the window reset, nil while it's the current one
+	End *time.Time
+	// ResetsAt is the reset time the CLI reported during the window
+	ResetsAt *time.Time
+	// Reason is how the reset was detected, empty for the current window
+	Reason string
+
+	Points int
+	Peak   int
+	// High is how long usage was above HighPercent
+	High time.Duration
+	// AtLimit is how long usage was at 100%
+	AtLimit time.Duration
+	// HitAt is when usage first reached 100%, nil if it never did
+	HitAt *time.Time
+}
+
+// ResetBetween reports whether the limit reset between two consecutive
+// samples of the same metric, and how that was detected
+func ResetBetween(prev, cur Sample) (string, bool) {
+	if cur.Percent < prev.Percent {
+		return ResetPercentDrop, true
+	}
+	if prev.ResetsAt != nil && cur.ResetsAt != nil && cur.ResetsAt.Sub(*prev.ResetsAt) > resetTolerance {
+		return ResetTimeChanged, true
+	}
+	return "", false
+}
+
+// windowBuilder splits the points of one metric into windows as they come
+type windowBuilder struct {
+	metric  string
+	current *Window
+	prev    Point
+	// start is where the next window starts, the end of the previous one
+	start *time.Time
+}
+
+// add counts the next point of the metric and returns the window it closed,
+// if it came after a reset
+func (b *windowBuilder) add(p Point) *Window {
+	var closed *Window
+	if b.current != nil {
+		if reason, ok := ResetBetween(b.prev.Sample, p.Sample); ok {
+			end := resetTime(b.prev, p)
+			b.current.close(b.prev, end, reason)
+			closed = b.current
+			b.current = nil
+			b.start = &end
+		} else {
+			b.current.add(b.prev, p.Time.Sub(b.prev.Time))
+		}
+	}
+
+	if b.current == nil {
+		start := p.Time
+		if b.start != nil {
+			start = *b.start
+		}
+		b.current = &Window{Metric: b.metric, Start: start}
+	}
+	b.current.observe(p)
+	b.prev = p
+	return closed
+}
+
+// resetTime returns when the limit reset between two points: the reset time
+// reported before it when it falls in between, the time of the first point
+// after it otherwise
+func resetTime(prev, cur Point) time.Time {
+	if prev.ResetsAt != nil && prev.ResetsAt.After(prev.Time) && !prev.ResetsAt.After(cur.Time) {
+		return *prev.ResetsAt
+	}
+	return cur.Time
+}
+
+// observe counts a point of the window
+func (w *Window) observe(p Point) {
+	w.Points++
+	w.Peak = max(w.Peak, p.Percent)
+	if p.ResetsAt != nil {
+		w.ResetsAt = p.ResetsAt
+	}
+	if p.Percent >= 100 && w.HitAt == nil {
+		t := p.Time
+		w.HitAt = &t
+	}
+}
+
+// add counts the time until the next point at the percentage of p
+func (w *Window) add(p Point, d time.Duration) {
+	d = min(d, maxGap)
+	if p.Percent > HighPercent {
+		w.High += d
+	}
+	if p.Percent >= 100 {
+		w.AtLimit += d
+	}
+}
+
+// close ends the window at end, last being its last point
+func (w *Window) close(last Point, end time.Time, reason string) {
+	w.add(last, end.Sub(last.Time))
+	w.End = &end
+	w.Reason = reason
+}
@@ -0,0 +1,155 @@
+package pricing
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/transcript"
+)
+
+// FileName is the pricing override read from the monitor's config directory
+const FileName = "pricing.json"
+
+//go:embed pricing.json
+var defaultPricing []byte
+
+// Price is what a model costs on the API, in USD per million tokens
+type Price struct {
+	// Match is a substring of the model ID, a full ID like "claude-opus-4-1"
+	// or a family like "opus" as a fallback. The longest matching entry wins.
+	Match      string  `json:"match"`
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
+// Table maps models to their prices
+type Table struct {
+	Models []Price `json:"models"`
+}
+
+// Default returns the built-in pricing table
+func Default() *Table {
+	var t Table
+	if err := json.Unmarshal(defaultPricing, &t); err != nil {
+		panic(fmt.Sprintf("invalid built-in pricing: %v", err))
+	}
+	return &t
+}
+
+// Load returns the built-in table with the entries of the file at path
+// layered on top: entries with the same match replace the built-in ones,
+// others are added. A missing file leaves the defaults as they are.
+func Load(path string) (*Table, error) {
+	t := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return t, nil
+		}
+		return t, err
+	}
+
+	var override Table
+	if err := json.Unmarshal(data, &override); err != nil {
+		return t, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for _, p := range override.Models {
+		replaced := false
+		for i := range t.Models {
+			if t.Models[i].Match == p.Match {
+				t.Models[i] = p
+				replaced = true
+			}
+		}
+		if !replaced {
+			t.Models = append(t.Models, p)
+		}
+	}
+	return t, nil
+}
+
+// Lookup returns the price of a model
+func (t *Table) Lookup(model string) (Price, bool) {
+	var best Price
+	found := false
+	for _, p := range t.Models {
+		if p.Match == "" || !strings.Contains(model, p.Match) {
+			continue
+		}
+		if !found || len(p.Match) > len(best.Match) {
+			best = p
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Cost estimates what tokens of a model would have cost on the API. ok is
+// false for models without a price.
+func (t *Table) Cost(model string, tokens transcript.Tokens) (cost float64, ok bool) {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(tokens.Input)*p.Input +
+		float64(tokens.Output)*p.Output +
+		float64(tokens.CacheRead)*p.CacheRead +
+		float64(tokens.CacheCreation)*p.CacheWrite) / 1e6, true
+}
+
+// Estimate is the cost of a set of buckets
+type Estimate struct {
+	Cost   float64
+	Tokens transcript.Tokens
+	// Unpriced lists models without a price, their tokens aren't in Cost
+	Unpriced []string
+}
+
+// Estimate totals the cost of buckets grouped by key, e.g. by day or model
+func (t *Table) Estimate(buckets []transcript.Bucket, key func(b transcript.Bucket) string) map[string]*Estimate {
+	estimates := make(map[string]*Estimate)
+	for _, b := range buckets {
+		k := key(b)
+		e, ok := estimates[k]
+		if !ok {
+			e = &Estimate{}
+			estimates[k] = e
+		}
+
+		e.Tokens = e.Tokens.Add(b.Tokens)
+		cost, ok := t.Cost(b.Model, b.Tokens)
+		if !ok {
+			if !contains(e.Unpriced, b.Model) {
+				e.Unpriced = append(e.Unpriced, b.Model)
+			}
+			continue
+		}
+		e.Cost += cost
+	}
+	return estimates
+}
+
+// Total estimates the cost of all buckets together
+func (t *Table) Total(buckets []transcript.Bucket) Estimate {
+	e, ok := t.Estimate(buckets, func(transcript.Bucket) string { return "" })[""]
+	if !ok {
+		return Estimate{}
+	}
+	return *e
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,497 @@
+package screen
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parser states
+const (
+	stateGround = iota
+	stateEscape
+	stateEscapeIntermediate
+	stateCSI
+	stateOSC
+	stateOSCEscape
+	stateString
+	stateStringEscape
+)
+
+// Screen is a minimal VT100/xterm screen model. Bytes written to it are
+// interpreted as terminal output, so cursor movements, erases and redraws
+// end up in the same rendered grid a real terminal would show.
+type Screen struct {
+	cols int
+	rows int
+
+	grid     [][]rune
+	mainGrid [][]rune // saved main buffer while the alternate screen is active
+
+	x, y        int
+	savedX      int
+	savedY      int
+	wrapPending bool
+
+	scrollTop    int
+	scrollBottom int
+
+	state   int
+	params  []byte
+	pending []byte // incomplete UTF-8 sequence from the previous write
+}
+
+// New creates a blank screen with the given size
+func New(cols, rows int) *Screen {
+	s := &Screen{
+		cols: cols,
+		rows: rows,
+	}
+	s.grid = s.blankGrid()
+	s.scrollBottom = rows - 1
+	return s
+}
+
+// Write feeds terminal output into the screen. It never fails.
+func (s *Screen) Write(p []byte) (int, error) {
+	data := p
+	if len(s.pending) > 0 {
+		data = append(s.pending, p...)
+		s.pending = nil
+	}
+
+	for i := 0; i < len(data); {
+		b := data[i]
+
+		if s.state != stateGround || b < 0x80 {
+			s.feedByte(b)
+			i++
+			continue
+		}
+
+		if !utf8.FullRune(data[i:]) {
+			s.pending = append([]byte(nil), data[i:]...)
+			break
+		}
+
+		r, size := utf8.DecodeRune(data[i:])
+		s.put(r)
+		i += size
+	}
+
+	return len(p), nil
+}
+
+// Lines returns the visible rows with trailing spaces removed
+func (s *Screen) Lines() []string {
+	lines := make([]string, s.rows)
+	for i, row := range s.grid {
+		lines[i] = strings.TrimRight(string(row), " ")
+	}
+	return lines
+}
+
+// String returns the visible screen as text, without trailing blank rows
+func (s *Screen) String() string {
+	lines := s.Lines()
+	end := len(lines)
+	for end > 0 && lines[end-1] == "" {
+		end--
+	}
+	return strings.Join(lines[:end], "\n")
+}
+
+// Contains reports whether text is visible anywhere on the screen
+func (s *Screen) Contains(text string) bool {
+	for _, line := range s.Lines() {
+		if strings.Contains(line, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Screen) feedByte(b byte) {
+	switch s.state {
+	case stateGround:
+		s.ground(b)
+
+	case stateEscape:
+		s.escape(b)
+
+	case stateEscapeIntermediate:
+		// Charset designation and similar: ESC ( B, ESC # 8, ...
+		if b >= 0x30 && b <= 0x7e {
+			s.state = stateGround
+		}
+
+	case stateCSI:
+		switch {
+		case b >= 0x40 && b <= 0x7e:
+			s.csi(b)
+			s.state = stateGround
+		case b == 0x1b:
+			s.state = stateEscape
+		default:
+			s.params = append(s.params, b)
+		}
+
+	case stateOSC:
+		switch b {
+		case 0x07:
+			s.state = stateGround
+		case 0x1b:
+			s.state = stateOSCEscape
+		}
+
+	case stateOSCEscape:
+		// ESC \ terminates, anything else is part of a broken sequence
+		s.state = stateGround
+		if b != '\\' {
+			s.escape(b)
+		}
+
+	case stateString:
+		if b == 0x1b {
+			s.state = stateStringEscape
+		}
+
+	case stateStringEscape:
+		if b == '\\' {
+			s.state = stateGround
+		} else {
+			s.state = stateString
+		}
+	}
+}
+
+func (s *Screen) ground(b byte) {
+	switch b {
+	case 0x1b:
+		s.state = stateEscape
+	case '\r':
+		s.x = 0
+		s.wrapPending = false
+	case '\n', 0x0b, 0x0c:
+		s.lineFeed()
+	case '\b':
+		if s.x > 0 {
+			s.x--
+		}
+		s.wrapPending = false
+	case '\t':
+		s.x = min((s.x/8+1)*8, s.cols-1)
+		s.wrapPending = false
+	default:
+		if b >= 0x20 && b != 0x7f {
+			s.put(rune(b))
+		}
+	}
+}
+
+func (s *Screen) escape(b byte) {
+	s.state = stateGround
+
+	switch b {
+	case '[':
+		s.params = s.params[:0]
+		s.state = stateCSI
+	case ']':
+		s.state = stateOSC
+	case 'P', 'X', '^', '_':
+		s.state = stateString
+	case '(', ')', '*', '+', '#', '%':
+		s.state = stateEscapeIntermediate
+	case '7':
+		s.saveCursor()
+	case '8':
+		s.restoreCursor()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.x = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset()
+	}
+}
+
+func (s *Screen) csi(final byte) {
+	private := len(s.params) > 0 && strings.IndexByte("?<=>", s.params[0]) >= 0
+	args := parseParams(s.params)
+
+	// Counts and positions past the screen size act like the size itself, and
+	// capping them keeps relative moves like x+n from overflowing
+	limit := max(s.cols, s.rows)
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] > 0 {
+			return min(args[i], limit)
+		}
+		return def
+	}
+
+	if private {
+		if final == 'h' || final == 'l' {
+			s.privateMode(args, final == 'h')
+		}
+		return
+	}
+
+	s.wrapPending = false
+
+	switch final {
+	case 'A':
+		s.y = max(s.y-arg(0, 1), 0)
+	case 'B', 'e':
+		s.y = min(s.y+arg(0, 1), s.rows-1)
+	case 'C', 'a':
+		s.x = min(s.x+arg(0, 1), s.cols-1)
+	case 'D':
+		s.x = max(s.x-arg(0, 1), 0)
+	case 'E':
+		s.x = 0
+		s.y = min(s.y+arg(0, 1), s.rows-1)
+	case 'F':
+		s.x = 0
+		s.y = max(s.y-arg(0, 1), 0)
+	case 'G', '`':
+		s.x = clamp(arg(0, 1)-1, 0, s.cols-1)
+	case 'H', 'f':
+		s.y = clamp(arg(0, 1)-1, 0, s.rows-1)
+		s.x = clamp(arg(1, 1)-1, 0, s.cols-1)
+	case 'd':
+		s.y = clamp(arg(0, 1)-1, 0, s.rows-1)
+	case 'J':
+		s.eraseDisplay(arg(0, 0))
+	case 'K':
+		s.eraseLine(arg(0, 0))
+	case 'X':
+		s.fill(s.y, s.x, min(s.x+arg(0, 1), s.cols))
+	case '@':
+		s.insertChars(arg(0, 1))
+	case 'P':
+		s.deleteChars(arg(0, 1))
+	case 'L':
+		s.insertLines(arg(0, 1))
+	case 'M':
+		s.deleteLines(arg(0, 1))
+	case 'S':
+		// Scrolling more than the region just blanks it
+		for i := 0; i < arg(0, 1); i++ {
+			s.scrollUp(s.scrollTop, s.scrollBottom)
+		}
+	case 'T':
+		for i := 0; i < arg(0, 1); i++ {
+			s.scrollDown(s.scrollTop, s.scrollBottom)
+		}
+	case 'r':
+		top := arg(0, 1) - 1
+		bottom := arg(1, s.rows) - 1
+		if top < bottom && bottom < s.rows {
+			s.scrollTop, s.scrollBottom = top, bottom
+			s.x, s.y = 0, 0
+		}
+	case 's':
+		s.saveCursor()
+	case 'u':
+		s.restoreCursor()
+	}
+}
+
+func (s *Screen) privateMode(args []int, set bool) {
+	for _, mode := range args {
+		switch mode {
+		case 47, 1047, 1049:
+			s.alternateScreen(set, mode == 1049)
+		}
+	}
+}
+
+func (s *Screen) alternateScreen(enable, saveCursor bool) {
+	if enable && s.mainGrid == nil {
+		if saveCursor {
+			s.saveCursor()
+		}
+		s.mainGrid = s.grid
+		s.grid = s.blankGrid()
+	} else if !enable && s.mainGrid != nil {
+		s.grid = s.mainGrid
+		s.mainGrid = nil
+		if saveCursor {
+			s.restoreCursor()
+		}
+	}
+}
+
+// put writes a printable rune at the cursor, wrapping at the right margin
+func (s *Screen) put(r rune) {
+	if s.wrapPending {
+		s.x = 0
+		s.lineFeed()
+	}
+
+	s.grid[s.y][s.x] = r
+
+	if s.x == s.cols-1 {
+		s.wrapPending = true
+	} else {
+		s.x++
+	}
+}
+
+func (s *Screen) lineFeed() {
+	s.wrapPending = false
+	if s.y == s.scrollBottom {
+		s.scrollUp(s.scrollTop, s.scrollBottom)
+	} else if s.y < s.rows-1 {
+		s.y++
+	}
+}
+
+func (s *Screen) reverseIndex() {
+	if s.y == s.scrollTop {
+		s.scrollDown(s.scrollTop, s.scrollBottom)
+	} else if s.y > 0 {
+		s.y--
+	}
+}
+
+// scrollUp moves rows top+1..bottom up by one and blanks the bottom row
+func (s *Screen) scrollUp(top, bottom int) {
+	first := s.grid[top]
+	copy(s.grid[top:bottom], s.grid[top+1:bottom+1])
+	s.grid[bottom] = first
+	s.fill(bottom, 0, s.cols)
+}
+
+// scrollDown moves rows top..bottom-1 down by one and blanks the top row
+func (s *Screen) scrollDown(top, bottom int) {
+	last := s.grid[bottom]
+	copy(s.grid[top+1:bottom+1], s.grid[top:bottom])
+	s.grid[top] = last
+	s.fill(top, 0, s.cols)
+}
+
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.fill(s.y, s.x, s.cols)
+		for row := s.y + 1; row < s.rows; row++ {
+			s.fill(row, 0, s.cols)
+		}
+	case 1:
+		for row := 0; row < s.y; row++ {
+			s.fill(row, 0, s.cols)
+		}
+		s.fill(s.y, 0, s.x+1)
+	case 2:
+		for row := 0; row < s.rows; row++ {
+			s.fill(row, 0, s.cols)
+		}
+	}
+}
+
+func (s *Screen) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		s.fill(s.y, s.x, s.cols)
+	case 1:
+		s.fill(s.y, 0, s.x+1)
+	case 2:
+		s.fill(s.y, 0, s.cols)
+	}
+}
+
+func (s *Screen) insertChars(n int) {
+	row := s.grid[s.y]
+	n = min(n, s.cols-s.x)
+	copy(row[s.x+n:], row[s.x:s.cols-n])
+	s.fill(s.y, s.x, s.x+n)
+}
+
+func (s *Screen) deleteChars(n int) {
+	row := s.grid[s.y]
+	n = min(n, s.cols-s.x)
+	copy(row[s.x:], row[s.x+n:])
+	s.fill(s.y, s.cols-n, s.cols)
+}
+
+func (s *Screen) insertLines(n int) {
+	if s.y < s.scrollTop || s.y > s.scrollBottom {
+		return
+	}
+	for i := 0; i < n; i++ {
+		s.scrollDown(s.y, s.scrollBottom)
+	}
+	s.x = 0
+}
+
+func (s *Screen) deleteLines(n int) {
+	if s.y < s.scrollTop || s.y > s.scrollBottom {
+		return
+	}
+	for i := 0; i < n; i++ {
+		first := s.grid[s.y]
+		copy(s.grid[s.y:s.scrollBottom], s.grid[s.y+1:s.scrollBottom+1])
+		s.grid[s.scrollBottom] = first
+		s.fill(s.scrollBottom, 0, s.cols)
+	}
+	s.x = 0
+}
+
+func (s *Screen) saveCursor() {
+	s.savedX, s.savedY = s.x, s.y
+}
+
+func (s *Screen) restoreCursor() {
+	s.x, s.y = s.savedX, s.savedY
+	s.wrapPending = false
+}
+
+func (s *Screen) reset() {
+	s.grid = s.blankGrid()
+	s.mainGrid = nil
+	s.x, s.y = 0, 0
+	s.wrapPending = false
+	s.scrollTop, s.scrollBottom = 0, s.rows-1
+}
+
+// fill blanks the cells [from, to) of a row
+func (s *Screen) fill(row, from, to int) {
+	for col := max(from, 0); col < to && col < s.cols; col++ {
+		s.grid[row][col] = ' '
+	}
+}
+
+func (s *Screen) blankGrid() [][]rune {
+	grid := make([][]rune, s.rows)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", s.cols))
+	}
+	return grid
+}
+
+// parseParams splits CSI parameters like "1;31" into integers. Private
+// markers and sub-parameters are ignored, missing values become 0.
+func parseParams(raw []byte) []int {
+	text := strings.TrimLeft(string(raw), "?>=<")
+	if text == "" {
+		return nil
+	}
+
+	var args []int
+	for _, part := range strings.Split(text, ";") {
+		if i := strings.IndexAny(part, ": "); i >= 0 {
+			part = part[:i]
+		}
+		n, _ := strconv.Atoi(part)
+		args = append(args, n)
+	}
+	return args
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
@@ -0,0 +1,184 @@
+package screen
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		cols  int
+		rows  int
+		input string
+		want  string
+	}{
+		{
+			name:  "plain text",
+			input: "hello\r\nworld",
+			want:  "hello\nworld",
+		},
+		{
+			name:  "colors are dropped",
+			input: "\x1b[1m\x1b[38;5;75m42%\x1b[39m\x1b[22m used",
+			want:  "42% used",
+		},
+		{
+			name:  "carriage return overwrites",
+			input: "Loading…\r\x1b[KDone",
+			want:  "Done",
+		},
+		{
+			name:  "cursor position",
+			input: "\x1b[2;3Hx\x1b[1;1Hy",
+			want:  "y\n  x",
+		},
+		{
+			name:  "erase display",
+			input: "old\r\nold\x1b[2J\x1b[Hnew",
+			want:  "new",
+		},
+		{
+			name:  "wraps at the right margin",
+			cols:  4,
+			input: "abcdef",
+			want:  "abcd\nef",
+		},
+		{
+			name:  "scrolls at the bottom",
+			rows:  2,
+			input: "1\r\n2\r\n3",
+			want:  "2\n3",
+		},
+		{
+			name:  "alternate screen restores the main one",
+			input: "main\x1b[?1049h\x1b[Halt\x1b[?1049l",
+			want:  "main",
+		},
+		{
+			name:  "utf-8 split across writes",
+			input: "█",
+			want:  "█",
+		},
+		{
+			name:  "osc title is ignored",
+			input: "\x1b]0;title\x07text",
+			want:  "text",
+		},
+		{
+			name:  "private markers don't run the public command",
+			input: "ab\x1b[s\x1b[1;1H\x1b[<u\x1b[>1u\x1b[=1uc",
+			want:  "cb",
+		},
+		{
+			name:  "huge scroll counts are clamped",
+			rows:  3,
+			input: "1\r\n2\r\n3\x1b[999999999S\x1b[999999999T\x1b[H\x1b[999999999L\x1b[999999999Mx",
+			want:  "x",
+		},
+		{
+			name:  "overflowing cursor forward is clamped",
+			cols:  10,
+			rows:  5,
+			input: "a\x1b[99999999999999999999Cb",
+			want:  "a        b",
+		},
+		{
+			name:  "overflowing cursor forward (a) is clamped",
+			cols:  10,
+			rows:  5,
+			input: "a\x1b[99999999999999999999ab",
+			want:  "a        b",
+		},
+		{
+			name:  "overflowing cursor down is clamped",
+			cols:  10,
+			rows:  5,
+			input: "\n\x1b[99999999999999999999Bb",
+			want:  "\n\n\n\nb",
+		},
+		{
+			name:  "overflowing cursor down (e) is clamped",
+			cols:  10,
+			rows:  5,
+			input: "\n\x1b[99999999999999999999eb",
+			want:  "\n\n\n\nb",
+		},
+		{
+			name:  "overflowing next line is clamped",
+			cols:  10,
+			rows:  5,
+			input: "\n\x1b[99999999999999999999Eb",
+			want:  "\n\n\n\nb",
+		},
+		{
+			name:  "insert lines",
+			rows:  3,
+			input: "1\r\n2\r\n3\x1b[2H\x1b[L",
+			want:  "1\n\n2",
+		},
+		{
+			name:  "delete lines",
+			rows:  3,
+			input: "1\r\n2\r\n3\x1b[1H\x1b[M",
+			want:  "2\n3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, rows := tt.cols, tt.rows
+			if cols == 0 {
+				cols = 80
+			}
+			if rows == 0 {
+				rows = 10
+			}
+
+			s := New(cols, rows)
+			// Byte by byte, so sequences split across writes are covered too
+			for _, b := range []byte(tt.input) {
+				s.Write([]byte{b})
+			}
+			if got := s.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// usage.raw is the byte stream of a /usage run: the prompt, a loading frame
+// and the usage panel, each redrawn over the previous one
+func TestWriteUsageCapture(t *testing.T) {
+	raw, err := os.ReadFile("testdata/usage.raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(200, 50)
+	s.Write(raw)
+
+	lines := s.Lines()
+	want := map[int]string{
+		1:  " Settings:  Status   Config   Usage",
+		3:  " Current session",
+		4:  " " + strings.Repeat("█", 21) + strings.Repeat(" ", 29) + "42% used",
+		5:  " Resets 10pm (America/Sao_Paulo)",
+		7:  " Current week (all models)",
+		11: " Current week (Sonnet only)",
+		12: " " + strings.Repeat("█", 2) + strings.Repeat(" ", 48) + "5% used",
+		15: " Esc to cancel",
+	}
+	for row, line := range want {
+		if lines[row] != line {
+			t.Errorf("line %d: got %q, want %q", row, lines[row], line)
+		}
+	}
+
+	for _, stale := range []string{"Loading usage", "? for shortcuts", "> /usage"} {
+		if s.Contains(stale) {
+			t.Errorf("screen still shows %q from an earlier frame", stale)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package sessions
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/fsutil"
+)
+
+// Hook events with a meaning for session state. Others are recorded as activity.
+const (
+	EventSessionStart = "SessionStart"
+	EventSessionEnd   = "SessionEnd"
+	EventStop         = "Stop"
+	EventPostToolUse  = "PostToolUse"
+)
+
+const (
+	// ActiveTimeout is how long a session without events still counts as open.
+	// Sessions closed without a SessionEnd hook would otherwise stay forever.
+	ActiveTimeout = 2 * time.Hour
+
+	// compactSize is the store size above which old events are dropped
+	compactSize = 1 << 20
+
+	// retention is how long events are kept when compacting
+	retention = 7 * 24 * time.Hour
+
+	lockTimeout = 5 * time.Second
+)
+
+// Event is a Claude Code hook call
+type Event struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	SessionID string    `json:"session_id"`
+	CWD       string    `json:"cwd,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Tool      string    `json:"tool,omitempty"`
+	// ConfigDir is the CLAUDE_CONFIG_DIR of the claude that ran the hook
+	ConfigDir string `json:"config_dir,omitempty"`
+}
+
+// hookInput is the JSON Claude Code passes to hooks on stdin
+type hookInput struct {
+	SessionID     string          `json:"session_id"`
+	CWD           string          `json:"cwd"`
+	HookEventName string          `json:"hook_event_name"`
+	ToolName      string          `json:"tool_name"`
+	Model         json.RawMessage `json:"model"`
+}
+
+// ParseHookInput reads the hook JSON for event from r
+func ParseHookInput(event string, r io.Reader) (Event, error) {
+	var in hookInput
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return Event{}, fmt.Errorf("failed to parse hook input: %w", err)
+	}
+	if in.SessionID == "" {
+		return Event{}, fmt.Errorf("hook input has no session_id")
+	}
+	if event == "" {
+		event = in.HookEventName
+	}
+
+	return Event{
+		Time:      time.Now().UTC(),
+		Event:     event,
+		SessionID: in.SessionID,
+		CWD:       in.CWD,
+		Model:     modelName(in.Model),
+		Tool:      in.ToolName,
+		ConfigDir: os.Getenv("CLAUDE_CONFIG_DIR"),
+	}, nil
+}
+
+// modelName accepts the model as a plain string or as {"id": ..., "display_name": ...}
+func modelName(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var name string
+	if json.Unmarshal(raw, &name) == nil {
+		return name
+	}
+	var obj struct {
+		ID          string `json:"id"`
+		DisplayName string `json:"display_name"`
+	}
+	if json.Unmarshal(raw, &obj) == nil {
+		if obj.ID != "" {
+			return obj.ID
+		}
+		return obj.DisplayName
+	}
+	return ""
+}
+
+// Store is an append-only JSONL file of hook events
+type Store struct {
+	path string
+}
+
+// NewStore creates a Store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Append records an event. Hooks run concurrently from several claude
+// sessions, so appends and compaction happen under a lock.
+func (s *Store) Append(e Event) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	unlock, err := fsutil.Lock(s.path+".lock", lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(line)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(s.path); err == nil && info.Size() > compactSize {
+		return s.compact()
+	}
+	return nil
+}
+
+// Events returns the events recorded since the given time, oldest first
+func (s *Store) Events(since time.Time) ([]Event, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		// Skip lines cut short by a crash
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		if e.Time.Before(since) {
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events, nil
+}
+
+// compact drops events older than the retention period. The lock must be held.
+func (s *Store) compact() error {
+	events, err := s.Events(time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return fsutil.WriteFileAtomic(s.path, buf.Bytes(), 0644)
+}
+
+// Session is the activity of a claude session, built from its hook events
+type Session struct {
+	ID           string
+	CWD          string
+	Model        string
+	Started      time.Time
+	LastActivity time.Time
+	LastEvent    string
+	ToolUses     int
+	Ended        bool
+}
+
+// Active reports whether the session is still open at now
+func (s *Session) Active(now time.Time) bool {
+	return !s.Ended && now.Sub(s.LastActivity) < ActiveTimeout
+}
+
+// Working reports whether claude is in the middle of a turn, as opposed to
+// waiting for the user
+func (s *Session) Working() bool {
+	return s.LastEvent != EventStop && s.LastEvent != EventSessionStart && !s.Ended
+}
+
+// Sessions groups events by session, most recently active first
+func Sessions(events []Event) []*Session {
+	byID := make(map[string]*Session)
+	var sessions []*Session
+
+	for _, e := range events {
+		s, ok := byID[e.SessionID]
+		if !ok {
+			s = &Session{ID: e.SessionID, Started: e.Time}
+			byID[e.SessionID] = s
+			sessions = append(sessions, s)
+		}
+
+		if e.CWD != "" {
+			s.CWD = e.CWD
+		}
+		if e.Model != "" {
+			s.Model = e.Model
+		}
+		s.LastActivity = e.Time
+		s.LastEvent = e.Event
+
+		switch e.Event {
+		case EventSessionStart:
+			// A resumed or cleared session starts over
+			s.Ended = false
+		case EventSessionEnd:
+			s.Ended = true
+		case EventPostToolUse:
+			s.ToolUses++
+		}
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LastActivity.After(sessions[j].LastActivity)
+	})
+	return sessions
+}
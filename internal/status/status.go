@@ -0,0 +1,65 @@
+package status
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/fsutil"
+)
+
+// FileName is the status sidecar written next to claude-code-usage.json
+const FileName = "claude-code-status.json"
+
+// Status is the outcome of the last collection run. No status file means
+// the monitor never ran; LastError means the last run failed and the usage
+// JSON is what LastSuccess left behind.
+type Status struct {
+	LastAttempt time.Time `json:"last_attempt"`
+	// LastSuccess is when usage data was last written, nil if never
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	// DurationSeconds is how long the last run took, retries included
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Attempts is how many times the last run tried to collect
+	Attempts int `json:"attempts"`
+	// ConsecutiveFailures is how many runs in a row failed, 0 after a success
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// LastError is set when the last run failed, and cleared by a success
+	LastError *Error `json:"last_error,omitempty"`
+}
+
+// Error describes why the last run failed and how to fix it
+type Error struct {
+	Kind    string `json:"kind"`
+	Summary string `json:"summary"`
+	Message string `json:"message"`
+	Hint    string `json:"hint"`
+}
+
+// Load reads the status file at path
+func Load(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Status
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save writes the status file at path atomically
+func Save(path string, s *Status) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
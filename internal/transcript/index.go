@@ -0,0 +1,364 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/fsutil"
+)
+
+const (
+	stateVersion = 1
+
+	// How long message keys are remembered to skip duplicates. Resumed
+	// sessions copy earlier messages into a new transcript.
+	seenRetention = 7 * 24 * time.Hour
+
+	// How long buckets keep their hour and session. Older ones are rolled up
+	// into days, which is all the views reading past the last month need.
+	hourlyRetention = 35 * 24 * time.Hour
+
+	lockTimeout = 10 * time.Second
+)
+
+// Index accumulates the token usage recorded in claude's transcripts. Files
+// are read incrementally: only lines appended since the last Update are
+// parsed, and the progress is kept in a state file.
+type Index struct {
+	dirs      []string
+	statePath string
+	state     *state
+}
+
+type state struct {
+	Version int                  `json:"version"`
+	Files   map[string]fileState `json:"files"`
+	Buckets []Bucket             `json:"buckets"`
+	// Seen maps message keys to their timestamp (Unix seconds)
+	Seen map[string]int64 `json:"seen"`
+
+	roots map[string]string
+}
+
+// fileState is how far a transcript has been read
+type fileState struct {
+	Offset int64 `json:"offset"`
+}
+
+// Open creates an Index for the transcripts in dirs, keeping its progress in
+// the file at statePath
+func Open(dirs []string, statePath string) (*Index, error) {
+	ix := &Index{dirs: dirs, statePath: statePath}
+	s, err := loadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+	ix.state = s
+	return ix, nil
+}
+
+// Update reads whatever was appended to the transcripts since the last
+// update and saves the progress. Nothing is written when no transcript grew.
+func (ix *Index) Update() error {
+	if err := os.MkdirAll(filepath.Dir(ix.statePath), 0755); err != nil {
+		return err
+	}
+	unlock, err := fsutil.Lock(ix.statePath+".lock", lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Another process may have made progress since we loaded the state
+	s, err := loadState(ix.statePath)
+	if err != nil {
+		return err
+	}
+
+	buckets := make(map[bucketKey]*Bucket, len(s.Buckets))
+	for i := range s.Buckets {
+		b := &s.Buckets[i]
+		buckets[b.key()] = b
+	}
+
+	files, err := ix.files()
+	if err != nil {
+		return err
+	}
+
+	moved := false
+	present := make(map[string]bool, len(files))
+	for _, file := range files {
+		present[file.path] = true
+		// Read up to its end last time, no need to open it
+		if previous, ok := s.Files[file.path]; ok && previous.Offset == file.size {
+			continue
+		}
+		offset, err := s.read(file.path, buckets)
+		if err != nil {
+			return fmt.Errorf("failed to read transcript %s: %w", file.path, err)
+		}
+		if previous, ok := s.Files[file.path]; !ok || previous.Offset != offset {
+			moved = true
+		}
+		s.Files[file.path] = fileState{Offset: offset}
+	}
+
+	// Forget deleted transcripts, their usage stays counted
+	for path := range s.Files {
+		if !present[path] {
+			delete(s.Files, path)
+			moved = true
+		}
+	}
+
+	if !moved {
+		ix.state = s
+		return nil
+	}
+
+	cutoff := time.Now().Add(-seenRetention).Unix()
+	for key, ts := range s.Seen {
+		if ts < cutoff {
+			delete(s.Seen, key)
+		}
+	}
+
+	s.Buckets = rollUp(buckets, StartOfDay(time.Now().Add(-hourlyRetention)))
+
+	if err := s.save(ix.statePath); err != nil {
+		return err
+	}
+	ix.state = s
+	return nil
+}
+
+// Buckets returns the hourly buckets whose hour falls in [from, to). Zero
+// times leave that end of the range open.
+func (ix *Index) Buckets(from, to time.Time) []Bucket {
+	var result []Bucket
+	for _, b := range ix.state.Buckets {
+		if !from.IsZero() && b.Hour.Before(from.Truncate(time.Hour)) {
+			continue
+		}
+		if !to.IsZero() && !b.Hour.Before(to) {
+			continue
+		}
+		result = append(result, b)
+	}
+	return result
+}
+
+// rollUp returns the buckets sorted by hour, with those before cutoff merged
+// into one per local day, model, project and branch. The map points into
+// the old slice, so the result is a new one.
+func rollUp(buckets map[bucketKey]*Bucket, cutoff time.Time) []Bucket {
+	merged := make(map[bucketKey]*Bucket, len(buckets))
+	for _, b := range buckets {
+		rolled := *b
+		if rolled.Hour.Before(cutoff) {
+			rolled.Hour = StartOfDay(rolled.Hour).UTC()
+			rolled.SessionID = ""
+		}
+		if existing, ok := merged[rolled.key()]; ok {
+			existing.Tokens = existing.Tokens.Add(rolled.Tokens)
+			continue
+		}
+		merged[rolled.key()] = &rolled
+	}
+
+	result := make([]Bucket, 0, len(merged))
+	for _, b := range merged {
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Hour.Before(result[j].Hour) })
+	return result
+}
+
+// transcriptFile is a transcript and its size when it was listed
+type transcriptFile struct {
+	path string
+	size int64
+}
+
+// files lists the transcripts, including those of subagents
+func (ix *Index) files() ([]transcriptFile, error) {
+	var files []transcriptFile
+	for _, dir := range ix.dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			files = append(files, transcriptFile{path: path, size: info.Size()})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// read adds the complete lines of path past its saved offset to buckets and
+// returns the new offset
+func (s *state) read(path string, buckets map[bucketKey]*Bucket) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	offset := s.Files[path].Offset
+	if info.Size() < offset {
+		// Rewritten from scratch; seen keys keep recent lines from counting twice
+		offset = 0
+	}
+	if info.Size() == offset {
+		return offset, nil
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(f)
+	for {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// A partial line is still being written, pick it up next time
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		offset += int64(len(data))
+		s.add(data, buckets)
+	}
+}
+
+// add counts a single transcript line
+func (s *state) add(data []byte, buckets map[bucketKey]*Bucket) {
+	l, ok := parseLine(data)
+	if !ok {
+		return
+	}
+
+	if key := l.messageKey(); key != "" {
+		if _, dup := s.Seen[key]; dup {
+			return
+		}
+		s.Seen[key] = l.Timestamp.Unix()
+	}
+
+	b := Bucket{
+		Hour:      l.Timestamp.UTC().Truncate(time.Hour),
+		Model:     l.Message.Model,
+		SessionID: l.SessionID,
+		Project:   s.projectRoot(l.CWD),
+		Branch:    l.GitBranch,
+	}
+	existing, ok := buckets[b.key()]
+	if !ok {
+		existing = &b
+		buckets[b.key()] = existing
+	}
+	existing.Tokens = existing.Tokens.Add(l.tokens())
+}
+
+// projectRoot returns the git repository containing dir, so sessions started
+// in a subdirectory count towards their repository. Directories outside a
+// repository, or that no longer exist, are returned as they are.
+func (s *state) projectRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if root, ok := s.roots[dir]; ok {
+		return root
+	}
+	if s.roots == nil {
+		s.roots = make(map[string]string)
+	}
+
+	root := dir
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			root = d
+			break
+		}
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+	s.roots[dir] = root
+	return root
+}
+
+func loadState(path string) (*state, error) {
+	s := &state{
+		Version: stateVersion,
+		Files:   make(map[string]fileState),
+		Seen:    make(map[string]int64),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+
+	var loaded state
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if loaded.Version != stateVersion {
+		// Unknown layout, start over from the transcripts
+		return s, nil
+	}
+	if loaded.Files != nil {
+		s.Files = loaded.Files
+	}
+	if loaded.Seen != nil {
+		s.Seen = loaded.Seen
+	}
+	s.Buckets = loaded.Buckets
+	return s, nil
+}
+
+func (s *state) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,91 @@
+package transcript
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// transcriptLine returns an assistant message using 10 input tokens
+func transcriptLine(t *testing.T, id, session string, at time.Time) string {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"type":      "assistant",
+		"timestamp": at,
+		"sessionId": session,
+		"requestId": "req-" + id,
+		"message": map[string]any{
+			"id":    id,
+			"model": "claude-sonnet-4-5",
+			"usage": map[string]int{"input_tokens": 10},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data) + "\n"
+}
+
+func TestUpdateRollsUpOldBuckets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "project", "session.jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	old := StartOfDay(time.Now().AddDate(0, 0, -60)).Add(9 * time.Hour)
+	recent := time.Now().Add(-2 * time.Hour)
+	lines := transcriptLine(t, "a", "s1", old) +
+		transcriptLine(t, "b", "s2", old.Add(3*time.Hour)) +
+		transcriptLine(t, "c", "s1", recent)
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statePath := filepath.Join(t.TempDir(), "index.json")
+	ix, err := Open([]string{dir}, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	buckets := ix.Buckets(time.Time{}, time.Time{})
+	if len(buckets) != 2 {
+		t.Fatalf("got %d buckets, want 2: %+v", len(buckets), buckets)
+	}
+	if day := buckets[0]; !day.Hour.Equal(StartOfDay(old)) || day.SessionID != "" || day.Input != 20 {
+		t.Errorf("old hours aren't rolled up into their day: %+v", day)
+	}
+	if hour := buckets[1]; !hour.Hour.Equal(recent.UTC().Truncate(time.Hour)) || hour.SessionID != "s1" || hour.Input != 10 {
+		t.Errorf("recent hour lost its detail: %+v", hour)
+	}
+
+	// Unchanged files are skipped, appended lines are picked up
+	if err := ix.Update(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(transcriptLine(t, "d", "s1", recent)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	ix, err = Open([]string{dir}, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Update(); err != nil {
+		t.Fatal(err)
+	}
+	total := Sum(ix.Buckets(time.Time{}, time.Time{}), ByModel)["claude-sonnet-4-5"]
+	if total.Input != 40 {
+		t.Errorf("got %d input tokens, want 40", total.Input)
+	}
+}
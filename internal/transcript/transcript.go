@@ -0,0 +1,216 @@
+package transcript
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Tokens counts the tokens of one or more messages
+type Tokens struct {
+	Input         int64 `json:"input"`
+	Output        int64 `json:"output"`
+	CacheRead     int64 `json:"cache_read"`
+	CacheCreation int64 `json:"cache_creation"`
+}
+
+// Add returns the sum of t and o
+func (t Tokens) Add(o Tokens) Tokens {
+	return Tokens{
+		Input:         t.Input + o.Input,
+		Output:        t.Output + o.Output,
+		CacheRead:     t.CacheRead + o.CacheRead,
+		CacheCreation: t.CacheCreation + o.CacheCreation,
+	}
+}
+
+// Total returns all tokens together
+func (t Tokens) Total() int64 {
+	return t.Input + t.Output + t.CacheRead + t.CacheCreation
+}
+
+// Bucket is the token usage of one hour, for one model, session, project and
+// git branch. Hours are in UTC. Past the last month, buckets are rolled up
+// into one per local day, starting at midnight, without a session.
+type Bucket struct {
+	Hour      time.Time `json:"hour"`
+	Model     string    `json:"model"`
+	SessionID string    `json:"session_id"`
+	Project   string    `json:"project,omitempty"`
+	Branch    string    `json:"branch,omitempty"`
+	Tokens
+}
+
+type bucketKey struct {
+	hour      int64
+	model     string
+	sessionID string
+	project   string
+	branch    string
+}
+
+func (b *Bucket) key() bucketKey {
+	return bucketKey{b.Hour.Unix(), b.Model, b.SessionID, b.Project, b.Branch}
+}
+
+// Sum totals buckets grouped by key, e.g. by model or by session
+func Sum(buckets []Bucket, key func(b Bucket) string) map[string]Tokens {
+	totals := make(map[string]Tokens)
+	for _, b := range buckets {
+		k := key(b)
+		totals[k] = totals[k].Add(b.Tokens)
+	}
+	return totals
+}
+
+// ByModel groups buckets by model
+func ByModel(b Bucket) string { return b.Model }
+
+// BySession groups buckets by session
+func BySession(b Bucket) string { return b.SessionID }
+
+// ByProject groups buckets by the directory claude ran in
+func ByProject(b Bucket) string { return b.Project }
+
+// ByProjectBranch groups buckets by project and git branch, as "project@branch"
+func ByProjectBranch(b Bucket) string {
+	if b.Branch == "" {
+		return b.Project
+	}
+	return b.Project + "@" + b.Branch
+}
+
+// ByDay groups buckets by local calendar day (2006-01-02)
+func ByDay(b Bucket) string { return b.Hour.Local().Format("2006-01-02") }
+
+// ByWeek groups buckets by the local date of the Monday starting their week
+func ByWeek(b Bucket) string {
+	return StartOfWeek(b.Hour.Local()).Format("2006-01-02")
+}
+
+// StartOfDay returns local midnight of t's day
+func StartOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// StartOfWeek returns local midnight of the Monday of t's week
+func StartOfWeek(t time.Time) time.Time {
+	day := StartOfDay(t)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// Window is a 5-hour usage window, like the session limit of Claude plans.
+// It starts at the hour of its first message.
+type Window struct {
+	Start   time.Time
+	End     time.Time
+	ByModel map[string]Tokens
+	Total   Tokens
+}
+
+// WindowLength is the length of a session window
+const WindowLength = 5 * time.Hour
+
+// Windows splits buckets into 5-hour windows, oldest first
+func Windows(buckets []Bucket) []Window {
+	sorted := append([]Bucket(nil), buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Hour.Before(sorted[j].Hour) })
+
+	var windows []Window
+	for _, b := range sorted {
+		if len(windows) == 0 || !b.Hour.Before(windows[len(windows)-1].End) {
+			windows = append(windows, Window{
+				Start:   b.Hour,
+				End:     b.Hour.Add(WindowLength),
+				ByModel: make(map[string]Tokens),
+			})
+		}
+		w := &windows[len(windows)-1]
+		w.ByModel[b.Model] = w.ByModel[b.Model].Add(b.Tokens)
+		w.Total = w.Total.Add(b.Tokens)
+	}
+	return windows
+}
+
+// line is the part of a transcript line the accounting needs
+type line struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	SessionID string    `json:"sessionId"`
+	RequestID string    `json:"requestId"`
+	CWD       string    `json:"cwd"`
+	GitBranch string    `json:"gitBranch"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// parseLine returns the usage recorded by a transcript line. ok is false for
+// lines without usage, like user messages or summaries.
+func parseLine(data []byte) (l line, ok bool) {
+	if err := json.Unmarshal(data, &l); err != nil {
+		return l, false
+	}
+	if l.Type != "assistant" || l.Message.Usage == nil || l.Timestamp.IsZero() {
+		return l, false
+	}
+	// Messages claude made up itself (e.g. API errors) didn't use any tokens
+	if l.Message.Model == "" || l.Message.Model == "<synthetic>" {
+		return l, false
+	}
+	return l, true
+}
+
+// messageKey identifies an API response. A response with several content
+// blocks is written as several lines carrying the same usage.
+func (l *line) messageKey() string {
+	if l.Message.ID == "" {
+		return ""
+	}
+	return l.Message.ID + ":" + l.RequestID
+}
+
+func (l *line) tokens() Tokens {
+	u := l.Message.Usage
+	return Tokens{
+		Input:         u.InputTokens,
+		Output:        u.OutputTokens,
+		CacheRead:     u.CacheReadInputTokens,
+		CacheCreation: u.CacheCreationInputTokens,
+	}
+}
+
+// ProjectsDirs returns the directories holding the transcripts of a claude
+// config directory: configDir/projects, or ~/.claude/projects and
+// ~/.config/claude/projects when configDir is empty. Only existing ones are
+// returned.
+func ProjectsDirs(configDir string) []string {
+	var candidates []string
+	if configDir != "" {
+		candidates = []string{filepath.Join(configDir, "projects")}
+	} else if homeDir, err := os.UserHomeDir(); err == nil {
+		candidates = []string{
+			filepath.Join(homeDir, ".claude", "projects"),
+			filepath.Join(homeDir, ".config", "claude", "projects"),
+		}
+	}
+
+	var dirs []string
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,83 @@
+package trust
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Removed describes a trust entry deleted by Cleanup
+type Removed struct {
+	ConfigPath string
+	Dir        string
+	Legacy     bool
+}
+
+// Cleanup removes every trust entry recorded in the ledger, across all claude
+// config files. Untouched entries added by older versions, which didn't keep
+// a ledger, are removed from ~/.claude.json too: the directories holding
+// claude-code-usage.sh (added by the script) and the dirs in legacyDirs.
+func Cleanup(ledgerPath string, legacyDirs []string) ([]Removed, error) {
+	var removed []Removed
+
+	l, err := loadLedger(ledgerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range append([]ledgerEntry(nil), l.Entries...) {
+		m := New(entry.ConfigPath, ledgerPath)
+		if err := m.remove(entry.Dir, entry.Created); err != nil {
+			return removed, err
+		}
+		removed = append(removed, Removed{ConfigPath: entry.ConfigPath, Dir: entry.Dir})
+	}
+
+	configPath, err := ConfigPath("")
+	if err != nil {
+		return removed, err
+	}
+	m := New(configPath, ledgerPath)
+
+	obj, err := m.read()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return removed, nil
+		}
+		return removed, err
+	}
+	projects, err := projectsOf(obj)
+	if err != nil {
+		return removed, err
+	}
+
+	for _, p := range projects.members {
+		// Entries claude has since used for real are kept
+		if !isLegacyDir(p.key, legacyDirs) || !isUntouched(p.value) {
+			continue
+		}
+		if err := m.remove(p.key, true); err != nil {
+			return removed, err
+		}
+		removed = append(removed, Removed{ConfigPath: configPath, Dir: p.key, Legacy: true})
+	}
+
+	return removed, nil
+}
+
+// isLegacyDir reports whether dir was trusted by an older monitor version
+func isLegacyDir(dir string, legacyDirs []string) bool {
+	for _, legacy := range legacyDirs {
+		if filepath.Clean(dir) == filepath.Clean(legacy) {
+			return true
+		}
+	}
+
+	// The script trusted its own directory, inside the app bundle or a checkout
+	if strings.HasSuffix(dir, filepath.Join("ClaudeCodeMonitor.app", "Contents", "MacOS")) {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, "claude-code-usage.sh"))
+	return err == nil
+}
@@ -0,0 +1,242 @@
+package trust
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// member is a single key/value pair of a JSON object
+type member struct {
+	key   string
+	value json.RawMessage
+
+	// parsed members were read from the source, changed ones were set since
+	parsed  bool
+	changed bool
+	// space is the whitespace before the key, without the separating comma
+	space      []byte
+	keyStart   int
+	keyEnd     int
+	valueStart int
+	valueEnd   int
+}
+
+// object is a JSON object that keeps its key order. An object read from a
+// file is marshalled by splicing the changes into its source, so formatting
+// and the values it doesn't touch stay byte-for-byte intact, and rewriting
+// a config file only changes what was meant to change.
+type object struct {
+	members []member
+
+	src   []byte // nil for objects built from scratch
+	open  int    // just past the opening brace in src
+	close int    // the closing brace in src
+	end   int    // end of the last member in src, or open when there's none
+}
+
+func parseObject(data []byte) (object, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return object{}, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return object{}, fmt.Errorf("expected a JSON object")
+	}
+
+	obj := object{src: data, open: int(dec.InputOffset())}
+	obj.end = obj.open
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return object{}, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return object{}, fmt.Errorf("expected an object key")
+		}
+		keyEnd := int(dec.InputOffset())
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return object{}, err
+		}
+
+		// Between the previous member and the key there's only whitespace
+		// and a comma, so the first quote opens the key
+		keyStart := obj.end + bytes.IndexByte(data[obj.end:], '"')
+		space := data[obj.end:keyStart]
+		if i := bytes.IndexByte(space, ','); i >= 0 {
+			space = space[i+1:]
+		}
+
+		valueEnd := int(dec.InputOffset())
+		obj.members = append(obj.members, member{
+			key:        key,
+			value:      value,
+			parsed:     true,
+			space:      space,
+			keyStart:   keyStart,
+			keyEnd:     keyEnd,
+			valueStart: valueEnd - len(value),
+			valueEnd:   valueEnd,
+		})
+		obj.end = valueEnd
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return object{}, err
+	}
+	obj.close = int(dec.InputOffset()) - 1
+
+	return obj, nil
+}
+
+func (o object) get(key string) (json.RawMessage, bool) {
+	for _, m := range o.members {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+	return nil, false
+}
+
+func (o object) set(key string, value json.RawMessage) object {
+	members := slices.Clone(o.members)
+	for i := range members {
+		if members[i].key == key {
+			members[i].value = value
+			members[i].changed = true
+			o.members = members
+			return o
+		}
+	}
+	o.members = append(members, member{key: key, value: value})
+	return o
+}
+
+func (o object) delete(key string) object {
+	o.members = slices.DeleteFunc(slices.Clone(o.members), func(m member) bool { return m.key == key })
+	return o
+}
+
+// marshal encodes the object. One read from a source keeps that source's
+// formatting, others are indented with two spaces, like claude does.
+func (o object) marshal() ([]byte, error) {
+	if o.src == nil {
+		return o.marshalNew()
+	}
+
+	// Added members are laid out like the last member of the source
+	space, colon := o.newMemberLayout()
+
+	var buf bytes.Buffer
+	buf.Write(o.src[:o.open])
+	for i, m := range o.members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		switch {
+		case !m.parsed:
+			key, err := marshalString(m.key)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(space)
+			buf.Write(key)
+			buf.Write(colon)
+			if err := writeIndented(&buf, m.value, space); err != nil {
+				return nil, err
+			}
+		case m.changed:
+			buf.Write(m.space)
+			buf.Write(o.src[m.keyStart:m.valueStart])
+			buf.Write(m.value)
+		default:
+			buf.Write(m.space)
+			buf.Write(o.src[m.keyStart:m.valueEnd])
+		}
+	}
+
+	buf.Write(o.src[o.end:])
+	return buf.Bytes(), nil
+}
+
+// newMemberLayout returns the whitespace before the key of an added member
+// and what separates the key from its value
+func (o object) newMemberLayout() (space, colon []byte) {
+	for i := len(o.members) - 1; i >= 0; i-- {
+		if m := o.members[i]; m.parsed {
+			return m.space, o.src[m.keyEnd:m.valueStart]
+		}
+	}
+
+	// Nothing to copy: one level deeper than the closing brace, or on the
+	// same line when the object is written on one line
+	if bytes.IndexByte(o.src[o.open:o.close], '\n') < 0 {
+		return nil, []byte(": ")
+	}
+	space = append([]byte("\n"), o.closeIndent()...)
+	return append(space, "  "...), []byte(": ")
+}
+
+// closeIndent returns the whitespace the line of the closing brace starts with
+func (o object) closeIndent() []byte {
+	line := o.src[:o.close]
+	if i := bytes.LastIndexByte(line, '\n'); i >= 0 {
+		line = line[i+1:]
+	}
+	if len(bytes.TrimLeft(line, " \t")) > 0 {
+		return nil
+	}
+	return line
+}
+
+// marshalNew encodes an object built from scratch with two-space indentation
+func (o object) marshalNew() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o.members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalString(m.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// writeIndented writes a value at the nesting of a member preceded by space:
+// on one line if space has no line break, indented to match it otherwise
+func writeIndented(buf *bytes.Buffer, value json.RawMessage, space []byte) error {
+	i := bytes.LastIndexByte(space, '\n')
+	if i < 0 {
+		return json.Compact(buf, value)
+	}
+	return json.Indent(buf, value, string(space[i+1:]), "  ")
+}
+
+// marshalString encodes s without escaping HTML characters
+func marshalString(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,156 @@
+package trust
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestObjectMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		edit func(o object) object
+		want string
+	}{
+		{
+			name: "untouched",
+			src:  "{\n    \"a\":1,  \"b\" : [1,2]\n}\n",
+			edit: func(o object) object { return o },
+			want: "{\n    \"a\":1,  \"b\" : [1,2]\n}\n",
+		},
+		{
+			name: "set keeps the rest",
+			src:  "{\n    \"a\": 1,\n    \"b\": {\"x\":true}\n}\n",
+			edit: func(o object) object { return o.set("a", json.RawMessage("2")) },
+			want: "{\n    \"a\": 2,\n    \"b\": {\"x\":true}\n}\n",
+		},
+		{
+			name: "added member follows the last one's layout",
+			src:  "{\n    \"a\" : 1\n}",
+			edit: func(o object) object { return o.set("b", json.RawMessage(`{"x": [1]}`)) },
+			want: "{\n    \"a\" : 1,\n    \"b\" : {\n      \"x\": [\n        1\n      ]\n    }\n}",
+		},
+		{
+			name: "added to a one-line object",
+			src:  `{"a":1}`,
+			edit: func(o object) object { return o.set("b", json.RawMessage("{\n  \"x\": 1\n}")) },
+			want: `{"a":1,"b":{"x":1}}`,
+		},
+		{
+			name: "added to an empty object",
+			src:  "{\n}",
+			edit: func(o object) object { return o.set("a", json.RawMessage("1")) },
+			want: "{\n  \"a\": 1\n}",
+		},
+		{
+			name: "delete the first",
+			src:  "{\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3\n}",
+			edit: func(o object) object { return o.delete("a") },
+			want: "{\n  \"b\": 2,\n  \"c\": 3\n}",
+		},
+		{
+			name: "delete the middle",
+			src:  "{\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3\n}",
+			edit: func(o object) object { return o.delete("b") },
+			want: "{\n  \"a\": 1,\n  \"c\": 3\n}",
+		},
+		{
+			name: "delete the last",
+			src:  "{\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3\n}",
+			edit: func(o object) object { return o.delete("c") },
+			want: "{\n  \"a\": 1,\n  \"b\": 2\n}",
+		},
+		{
+			name: "delete the only one",
+			src:  "{\n  \"a\": 1\n}",
+			edit: func(o object) object { return o.delete("a") },
+			want: "{\n}",
+		},
+		{
+			name: "escaped keys are kept as written",
+			src:  `{"caf\u00e9": 1, "b": 2}`,
+			edit: func(o object) object { return o.set("b", json.RawMessage("3")) },
+			want: `{"caf\u00e9": 1, "b": 3}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := parseObject([]byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.edit(o).marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			if !json.Valid(got) {
+				t.Errorf("invalid JSON:\n%s", got)
+			}
+		})
+	}
+}
+
+// A config in a layout claude wouldn't write, with values on one line
+const userConfig = `{
+	"numStartups": 42,
+	"projects": {
+		"/Users/me/code": {"allowedTools": ["Bash"], "hasTrustDialogAccepted": true},
+		"/Users/me/other": {
+			"hasTrustDialogAccepted": false
+		}
+	},
+	"theme": "dark"
+}
+`
+
+func TestGrantRevokeKeepsFormatting(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".claude.json")
+	if err := os.WriteFile(configPath, []byte(userConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m := New(configPath, filepath.Join(dir, "trusted-dirs.json"))
+
+	for _, trusted := range []string{"/tmp/workspace", "/Users/me/other"} {
+		release, err := m.Grant(trusted)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := m.IsTrusted(trusted); err != nil || !ok {
+			t.Fatalf("%s not trusted after Grant (err %v):\n%s", trusted, err, data)
+		}
+		// Only the projects entry may change
+		for _, line := range []string{
+			"\t\"numStartups\": 42,",
+			"\t\t\"/Users/me/code\": {\"allowedTools\": [\"Bash\"], \"hasTrustDialogAccepted\": true},",
+			"\t\"theme\": \"dark\"\n}\n",
+		} {
+			if !strings.Contains(string(data), line) {
+				t.Errorf("Grant(%s) changed %q:\n%s", trusted, line, data)
+			}
+		}
+
+		if err := release(); err != nil {
+			t.Fatal(err)
+		}
+		data, err = os.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != userConfig {
+			t.Errorf("Revoke(%s) didn't restore the file:\n%s", trusted, data)
+		}
+	}
+}
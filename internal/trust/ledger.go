@@ -0,0 +1,75 @@
+package trust
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/fsutil"
+)
+
+// ledgerEntry records a trust edit made by the monitor
+type ledgerEntry struct {
+	ConfigPath string `json:"config_path"`
+	Dir        string `json:"dir"`
+	// Created is true when the monitor added the whole project entry, false
+	// when it only accepted the trust dialog on an existing one
+	Created bool      `json:"created"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type ledger struct {
+	Entries []ledgerEntry `json:"entries"`
+}
+
+func loadLedger(path string) (*ledger, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ledger{}, nil
+		}
+		return nil, err
+	}
+
+	var l ledger
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (l *ledger) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
+
+func (l *ledger) find(configPath, dir string) (ledgerEntry, bool) {
+	for _, e := range l.Entries {
+		if e.ConfigPath == configPath && e.Dir == dir {
+			return e, true
+		}
+	}
+	return ledgerEntry{}, false
+}
+
+func (l *ledger) add(entry ledgerEntry) {
+	l.remove(entry.ConfigPath, entry.Dir)
+	l.Entries = append(l.Entries, entry)
+}
+
+func (l *ledger) remove(configPath, dir string) {
+	entries := l.Entries[:0]
+	for _, e := range l.Entries {
+		if e.ConfigPath != configPath || e.Dir != dir {
+			entries = append(entries, e)
+		}
+	}
+	l.Entries = entries
+}
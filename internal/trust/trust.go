@@ -0,0 +1,270 @@
+package trust
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/fsutil"
+)
+
+// lockTimeout is how long to wait for another monitor process editing the same file
+const lockTimeout = 10 * time.Second
+
+// newProject is the entry claude itself writes for a trusted directory
+var newProject = json.RawMessage(`{
+  "allowedTools": [],
+  "mcpContextUris": [],
+  "mcpServers": {},
+  "enabledMcpjsonServers": [],
+  "disabledMcpjsonServers": [],
+  "hasTrustDialogAccepted": true,
+  "projectOnboardingSeenCount": 0,
+  "hasClaudeMdExternalIncludesApproved": false,
+  "hasClaudeMdExternalIncludesWarningShown": false,
+  "exampleFiles": []
+}`)
+
+// ConfigPath returns the global claude config file for a claude config
+// directory, or ~/.claude.json when configDir is empty
+func ConfigPath(configDir string) (string, error) {
+	if configDir != "" {
+		return filepath.Join(configDir, ".claude.json"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".claude.json"), nil
+}
+
+// Manager adds and removes directory trust entries in a claude config file.
+// Every edit happens under a lock with an atomic write, and is recorded in a
+// ledger so that it can be undone later, even after a crash.
+type Manager struct {
+	configPath string
+	ledgerPath string
+}
+
+// New creates a new Manager for the claude config at configPath, recording
+// its edits in the ledger at ledgerPath
+func New(configPath, ledgerPath string) *Manager {
+	return &Manager{
+		configPath: configPath,
+		ledgerPath: ledgerPath,
+	}
+}
+
+// IsTrusted reports whether dir has the trust dialog accepted. It never writes.
+func (m *Manager) IsTrusted(dir string) (bool, error) {
+	obj, err := m.read()
+	if err != nil {
+		return false, err
+	}
+	projects, err := projectsOf(obj)
+	if err != nil {
+		return false, err
+	}
+	return isTrusted(projects, dir), nil
+}
+
+// Grant makes sure dir is trusted. If it already is, nothing is written. The
+// returned function undoes the edit; it's a no-op when nothing was changed.
+func (m *Manager) Grant(dir string) (func() error, error) {
+	if trusted, err := m.IsTrusted(dir); err != nil {
+		return nil, err
+	} else if trusted {
+		return func() error { return nil }, nil
+	}
+
+	changed := false
+	created := false
+
+	err := m.update(func(projects object) (object, error) {
+		if isTrusted(projects, dir) {
+			return projects, nil
+		}
+
+		changed = true
+		existing, ok := projects.get(dir)
+		if !ok {
+			created = true
+			return projects.set(dir, newProject), nil
+		}
+
+		project, err := parseObject(existing)
+		if err != nil {
+			return object{}, fmt.Errorf("invalid project entry for %s: %w", dir, err)
+		}
+		value, err := project.set("hasTrustDialogAccepted", json.RawMessage("true")).marshal()
+		if err != nil {
+			return object{}, err
+		}
+		return projects.set(dir, value), nil
+	}, func(l *ledger) {
+		if changed {
+			l.add(ledgerEntry{ConfigPath: m.configPath, Dir: dir, Created: created, AddedAt: time.Now()})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !changed {
+		return func() error { return nil }, nil
+	}
+	return func() error { return m.Revoke(dir) }, nil
+}
+
+// Revoke undoes a trust edit recorded in the ledger for dir. Entries the
+// monitor didn't add are left alone.
+func (m *Manager) Revoke(dir string) error {
+	l, err := loadLedger(m.ledgerPath)
+	if err != nil {
+		return err
+	}
+	entry, ok := l.find(m.configPath, dir)
+	if !ok {
+		return nil
+	}
+	return m.remove(dir, entry.Created)
+}
+
+// remove deletes the entry for dir (created) or just its trust flag
+func (m *Manager) remove(dir string, created bool) error {
+	return m.update(func(projects object) (object, error) {
+		existing, ok := projects.get(dir)
+		if !ok {
+			return projects, nil
+		}
+		if created {
+			return projects.delete(dir), nil
+		}
+
+		project, err := parseObject(existing)
+		if err != nil {
+			return object{}, fmt.Errorf("invalid project entry for %s: %w", dir, err)
+		}
+		value, err := project.set("hasTrustDialogAccepted", json.RawMessage("false")).marshal()
+		if err != nil {
+			return object{}, err
+		}
+		return projects.set(dir, value), nil
+	}, func(l *ledger) {
+		l.remove(m.configPath, dir)
+	})
+}
+
+// update edits the "projects" object of the config file under the lock, then
+// updates the ledger while still holding it. The file is re-read right before
+// writing so concurrent edits by claude are kept.
+func (m *Manager) update(edit func(projects object) (object, error), record func(l *ledger)) error {
+	unlock, err := fsutil.Lock(m.configPath+".monitor-lock", lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	original, err := os.ReadFile(m.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("run 'claude' at least once to initialize configuration: %w", err)
+		}
+		return err
+	}
+
+	obj, err := parseObject(original)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", m.configPath, err)
+	}
+	projects, err := projectsOf(obj)
+	if err != nil {
+		return err
+	}
+
+	edited, err := edit(projects)
+	if err != nil {
+		return err
+	}
+
+	projectsJSON, err := edited.marshal()
+	if err != nil {
+		return err
+	}
+	updated, err := obj.set("projects", projectsJSON).marshal()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(updated, original) {
+		if !json.Valid(updated) {
+			return fmt.Errorf("refusing to write invalid JSON to %s", m.configPath)
+		}
+		if err := fsutil.WriteFileAtomic(m.configPath, updated, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", m.configPath, err)
+		}
+	}
+
+	l, err := loadLedger(m.ledgerPath)
+	if err != nil {
+		return err
+	}
+	record(l)
+	return l.save(m.ledgerPath)
+}
+
+func (m *Manager) read() (object, error) {
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return object{}, fmt.Errorf("run 'claude' at least once to initialize configuration: %w", err)
+		}
+		return object{}, err
+	}
+	obj, err := parseObject(data)
+	if err != nil {
+		return object{}, fmt.Errorf("failed to parse %s: %w", m.configPath, err)
+	}
+	return obj, nil
+}
+
+// projectsOf returns the "projects" object of a claude config
+func projectsOf(obj object) (object, error) {
+	raw, ok := obj.get("projects")
+	if !ok || string(raw) == "null" {
+		return object{}, nil
+	}
+	projects, err := parseObject(raw)
+	if err != nil {
+		return object{}, fmt.Errorf("invalid projects in claude config: %w", err)
+	}
+	return projects, nil
+}
+
+// isUntouched reports whether a project entry is still exactly the one the
+// monitor writes, i.e. claude was never really used in that directory
+func isUntouched(raw json.RawMessage) bool {
+	var project, template map[string]any
+	if json.Unmarshal(raw, &project) != nil || json.Unmarshal(newProject, &template) != nil {
+		return false
+	}
+	return reflect.DeepEqual(project, template)
+}
+
+func isTrusted(projects object, dir string) bool {
+	raw, ok := projects.get(dir)
+	if !ok {
+		return false
+	}
+	var project struct {
+		HasTrustDialogAccepted bool `json:"hasTrustDialogAccepted"`
+	}
+	if err := json.Unmarshal(raw, &project); err != nil {
+		return false
+	}
+	return project.HasTrustDialogAccepted
+}
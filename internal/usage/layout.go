@@ -0,0 +1,171 @@
+package usage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/screen"
+)
+
+// Layout is a known arrangement of the /usage screen: the limit blocks a
+// range of claude versions prints. Every layout is read by ParseScreen, the
+// table only tells which blocks to expect.
+type Layout struct {
+	Name string
+	// MinVersion is the first claude version known to print this layout
+	MinVersion string
+	// MaxVersion is the first version that printed another layout, empty for
+	// the newest one, which holds until a release changes the screen
+	MaxVersion string
+	// Limits are the IDs of the blocks the layout shows, in order
+	Limits []string
+}
+
+// KnownLayouts is the compatibility table, oldest first. A CLI update is
+// only flagged when its screen stops matching the newest layout.
+var KnownLayouts = []Layout{
+	{
+		// Weekly limits introduced an Opus-only block next to the overall one
+		Name:       "week-opus",
+		MinVersion: "1.0.0",
+		MaxVersion: "2.0.0",
+		Limits:     []string{SessionLimitID, "week_all_models", "week_opus"},
+	},
+	{
+		// The Opus block was replaced by a Sonnet-only one
+		Name:       "week-sonnet",
+		MinVersion: "2.0.0",
+		Limits:     []string{SessionLimitID, "week_all_models", "week_sonnet_only"},
+	},
+}
+
+var versionRe = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// ParseCLIVersion extracts the version from `claude --version` output, e.g.
+// "2.0.37 (Claude Code)" becomes "2.0.37"
+func ParseCLIVersion(output string) (string, bool) {
+	m := versionRe.FindString(output)
+	return m, m != ""
+}
+
+// LayoutFor returns the layout printed by a claude version. ok is false when
+// the version is unknown or older than every range in the table; the newest
+// layout is returned then.
+func LayoutFor(version string) (Layout, bool) {
+	newest := KnownLayouts[len(KnownLayouts)-1]
+	v, ok := versionNumbers(version)
+	if !ok {
+		return newest, false
+	}
+
+	for _, layout := range KnownLayouts {
+		min, _ := versionNumbers(layout.MinVersion)
+		max, bounded := versionNumbers(layout.MaxVersion)
+		if compareVersions(v, min) >= 0 && (!bounded || compareVersions(v, max) < 0) {
+			return layout, true
+		}
+	}
+	return newest, false
+}
+
+// ParseVersion parses a raw capture and checks it against the layout of the
+// claude version that printed it, or the newest layout when the version
+// isn't in the compatibility table. The version and layout are recorded in
+// the result, and a warning is set when the screen doesn't show the blocks
+// the layout has.
+func ParseVersion(raw []byte, version string) (*UsageData, error) {
+	layout, known := LayoutFor(version)
+
+	term := screen.New(ScreenCols, ScreenRows)
+	term.Write(raw)
+
+	data, err := ParseScreen(term.Lines())
+	if err != nil {
+		return nil, err
+	}
+
+	data.CLIVersion = version
+	data.Layout = layout.Name
+
+	if got := limitIDs(data); !sameIDs(got, layout.Limits) {
+		switch {
+		case version == "":
+			data.Warning = "unknown /usage layout for an unknown claude version"
+		case !known:
+			data.Warning = fmt.Sprintf("unknown /usage layout for claude %s, which is not in the compatibility table", version)
+		default:
+			data.Warning = fmt.Sprintf("unknown /usage layout for claude %s", version)
+		}
+		data.Warning += fmt.Sprintf(": expected %s, found %s", strings.Join(layout.Limits, ", "), strings.Join(got, ", "))
+	}
+
+	return data, nil
+}
+
+func limitIDs(data *UsageData) []string {
+	ids := make([]string, len(data.Limits))
+	for i, l := range data.Limits {
+		ids[i] = l.ID
+	}
+	return ids
+}
+
+// sameIDs reports whether a and b hold the same IDs, in any order
+func sameIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int)
+	for _, id := range a {
+		seen[id]++
+	}
+	for _, id := range b {
+		if seen[id] == 0 {
+			return false
+		}
+		seen[id]--
+	}
+	return true
+}
+
+// CompareVersions compares the first x.y.z versions found in a and b,
+// returning -1, 0 or 1. Strings without a version sort first.
+func CompareVersions(a, b string) int {
+	va, okA := versionNumbers(a)
+	vb, okB := versionNumbers(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	return compareVersions(va, vb)
+}
+
+func versionNumbers(version string) ([3]int, bool) {
+	var v [3]int
+	m := versionRe.FindStringSubmatch(version)
+	if m == nil {
+		return v, false
+	}
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1])
+	}
+	return v, true
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
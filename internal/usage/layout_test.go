@@ -0,0 +1,62 @@
+package usage
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLayoutFor(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		known   bool
+	}{
+		{"1.0.0", "week-opus", true},
+		{"1.9.99", "week-opus", true},
+		{"2.0.0", "week-sonnet", true},
+		{"2.0.37 (Claude Code)", "week-sonnet", true},
+		{"7.3.1", "week-sonnet", true},
+		{"0.2.9", "week-sonnet", false},
+		{"", "week-sonnet", false},
+	}
+
+	for _, tt := range tests {
+		layout, known := LayoutFor(tt.version)
+		if layout.Name != tt.want || known != tt.known {
+			t.Errorf("LayoutFor(%q) = %s, %v, want %s, %v", tt.version, layout.Name, known, tt.want, tt.known)
+		}
+	}
+}
+
+// usage.raw prints the session, all models and Sonnet only blocks
+func TestParseVersionWarning(t *testing.T) {
+	raw, err := os.ReadFile("testdata/usage.raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version string
+		warning string // a substring of the expected warning, empty for none
+	}{
+		{"2.0.37", ""},
+		{"3.0.0", ""},
+		{"", ""},
+		{"0.9.0", ""},
+		{"1.0.88", "expected session, week_all_models, week_opus"},
+	}
+
+	for _, tt := range tests {
+		data, err := ParseVersion(raw, tt.version)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q): %v", tt.version, err)
+		}
+		switch {
+		case tt.warning == "" && data.Warning != "":
+			t.Errorf("ParseVersion(%q) warns %q", tt.version, data.Warning)
+		case tt.warning != "" && !strings.Contains(data.Warning, tt.warning):
+			t.Errorf("ParseVersion(%q) warns %q, want %q", tt.version, data.Warning, tt.warning)
+		}
+	}
+}
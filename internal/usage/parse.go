@@ -0,0 +1,104 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/screen"
+)
+
+var (
+	// ErrSessionNotFound is returned when the output doesn't contain the usage screen
+	ErrSessionNotFound = errors.New("'Current session' not found in output")
+
+	// ErrPercentNotFound is returned when a usage block has no "% used" value
+	ErrPercentNotFound = errors.New("usage percentage not found")
+)
+
+const (
+	// ScreenCols and ScreenRows are the terminal size /usage is captured and
+	// replayed in. It's wide enough that bars and reset text never wrap.
+	ScreenCols = 200
+	ScreenRows = 50
+
+	// blockLines is how many lines after a header are searched for its values
+	blockLines = 3
+)
+
+var (
+	headerRe  = regexp.MustCompile(`^[\s│|]*(Current \S.*?)[\s│|]*$`)
+	percentRe = regexp.MustCompile(`(\d+)%\s*used`)
+	resetRe   = regexp.MustCompile(`Resets\s*(.*)`)
+)
+
+// Parse replays the raw output of `claude /usage` through a terminal screen
+// model and extracts usage data from the rendered screen
+func Parse(raw []byte) (*UsageData, error) {
+	term := screen.New(ScreenCols, ScreenRows)
+	term.Write(raw)
+	return ParseScreen(term.Lines())
+}
+
+// ParseScreen extracts usage data from the lines of a rendered /usage
+// screen. Every "Current …" block with a percentage becomes a limit.
+func ParseScreen(lines []string) (*UsageData, error) {
+	usage := &UsageData{}
+	sessionFound := false
+
+	for i, line := range lines {
+		m := headerRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := m[1]
+
+		percent, reset, ok := parseBlock(lines[i+1:])
+		if !ok {
+			if limitID(name) == SessionLimitID {
+				return nil, fmt.Errorf("%q: %w", name, ErrPercentNotFound)
+			}
+			continue
+		}
+
+		limit := NewLimit(name, percent, reset)
+		if _, exists := usage.Limit(limit.ID); exists {
+			continue
+		}
+		if limit.ID == SessionLimitID {
+			sessionFound = true
+		}
+		usage.Limits = append(usage.Limits, limit)
+	}
+
+	if !sessionFound {
+		return nil, ErrSessionNotFound
+	}
+
+	usage.fillLegacy()
+
+	return usage, nil
+}
+
+// parseBlock reads the percentage and reset text that follow a block
+// header, stopping at the next header
+func parseBlock(lines []string) (int, string, bool) {
+	percent := -1
+	reset := ""
+
+	for _, line := range lines[:min(blockLines, len(lines))] {
+		if headerRe.MatchString(line) {
+			break
+		}
+		if m := percentRe.FindStringSubmatch(line); m != nil {
+			percent, _ = strconv.Atoi(m[1])
+		}
+		if m := resetRe.FindStringSubmatch(line); m != nil {
+			reset = strings.TrimSpace(m[1])
+		}
+	}
+
+	return percent, reset, percent >= 0
+}
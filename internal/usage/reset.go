@@ -0,0 +1,87 @@
+package usage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embedded zone database, so reset zones resolve even without system tzdata
+	_ "time/tzdata"
+)
+
+// resetTextRe matches reset texts like "10pm", "9:30am", "Nov 21 at 9pm" or
+// "Nov 21, 9pm", with an optional "(America/Sao_Paulo)" zone suffix
+var resetTextRe = regexp.MustCompile(`(?i)^(?:([a-z]{3})[a-z]*\s+(\d{1,2}),?\s+(?:at\s+)?)?(\d{1,2})(?::(\d{2}))?\s*(am|pm)\s*(?:\(([^)]+)\))?$`)
+
+// ParseReset turns a reset text from the /usage screen into an absolute time
+// in the zone it names. Without a zone, now's location is used. A bare time
+// like "10pm" resolves to its next occurrence after now, and a date without
+// a year to the nearest one that isn't in the past.
+func ParseReset(text string, now time.Time) (time.Time, error) {
+	m := resetTextRe.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return time.Time{}, fmt.Errorf("unrecognized reset time %q", text)
+	}
+
+	loc := now.Location()
+	if m[6] != "" {
+		zone, err := time.LoadLocation(strings.TrimSpace(m[6]))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone in %q: %w", text, err)
+		}
+		loc = zone
+	}
+	now = now.In(loc)
+
+	hour, _ := strconv.Atoi(m[3])
+	minute, _ := strconv.Atoi(m[4])
+	if hour < 1 || hour > 12 || minute > 59 {
+		return time.Time{}, fmt.Errorf("invalid time in %q", text)
+	}
+	hour %= 12
+	if strings.EqualFold(m[5], "pm") {
+		hour += 12
+	}
+
+	// Bare time of day: next occurrence
+	if m[1] == "" {
+		t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, loc)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	month, err := time.Parse("Jan", strings.ToUpper(m[1][:1])+strings.ToLower(m[1][1:]))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month in %q", text)
+	}
+	day, _ := strconv.Atoi(m[2])
+
+	t := time.Date(now.Year(), month.Month(), day, hour, minute, 0, 0, loc)
+	if t.Month() != month.Month() {
+		return time.Time{}, fmt.Errorf("invalid date in %q", text)
+	}
+	// "Jan 2" seen in late December belongs to next year
+	if t.Before(now.AddDate(0, 0, -1)) {
+		t = t.AddDate(1, 0, 0)
+	}
+
+	return t, nil
+}
+
+// ResolveResets parses the reset text of every limit relative to now.
+// Limits whose text can't be parsed keep a nil ResetsAt.
+func (u *UsageData) ResolveResets(now time.Time) {
+	for i := range u.Limits {
+		l := &u.Limits[i]
+		if l.Reset == "" {
+			continue
+		}
+		if t, err := ParseReset(l.Reset, now); err == nil {
+			l.ResetsAt = &t
+		}
+	}
+}
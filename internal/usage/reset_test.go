@@ -0,0 +1,135 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReset(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		now  time.Time
+		want time.Time
+	}{
+		{
+			name: "bare hour later today",
+			text: "10pm",
+			now:  time.Date(2025, 11, 20, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 20, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "bare hour already past rolls to tomorrow",
+			text: "9am",
+			now:  time.Date(2025, 11, 20, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 21, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "bare hour at now is the next day",
+			text: "3pm",
+			now:  time.Date(2025, 11, 20, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 21, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "minutes",
+			text: "9:30am",
+			now:  time.Date(2025, 11, 20, 8, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 20, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "midnight",
+			text: "12am",
+			now:  time.Date(2025, 11, 20, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 21, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "date this year",
+			text: "Nov 21 at 9pm",
+			now:  time.Date(2025, 11, 20, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 21, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "date with comma",
+			text: "Nov 21, 9pm",
+			now:  time.Date(2025, 11, 20, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 21, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "january seen in december is next year",
+			text: "Jan 2, 9am",
+			now:  time.Date(2025, 12, 30, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "date earlier today stays this year",
+			text: "Dec 30, 9am",
+			now:  time.Date(2025, 12, 30, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 12, 30, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "iana zone",
+			text: "10pm (America/Sao_Paulo)",
+			now:  time.Date(2025, 11, 20, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 20, 22, 0, 0, 0, saoPaulo),
+		},
+		{
+			name: "iana zone already past its evening",
+			text: "10pm (America/Sao_Paulo)",
+			now:  time.Date(2025, 11, 21, 2, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 21, 22, 0, 0, 0, saoPaulo),
+		},
+		{
+			name: "iana zone with a date",
+			text: "Nov 3, 9am (Asia/Tokyo)",
+			now:  time.Date(2025, 10, 31, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 11, 3, 9, 0, 0, 0, tokyo),
+		},
+		{
+			name: "now's zone without a suffix",
+			text: "10pm",
+			now:  time.Date(2025, 11, 20, 15, 0, 0, 0, tokyo),
+			want: time.Date(2025, 11, 20, 22, 0, 0, 0, tokyo),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReset(tt.text, tt.now)
+			if err != nil {
+				t.Fatalf("ParseReset(%q): %v", tt.text, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseReset(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			if got.Location().String() != tt.want.Location().String() {
+				t.Errorf("ParseReset(%q) is in %v, want %v", tt.text, got.Location(), tt.want.Location())
+			}
+		})
+	}
+}
+
+func TestParseResetInvalid(t *testing.T) {
+	now := time.Date(2025, 11, 20, 15, 0, 0, 0, time.UTC)
+	for _, text := range []string{
+		"",
+		"soon",
+		"13pm",
+		"9:75am",
+		"Feb 30, 9am",
+		"Foo 3, 9am",
+		"10pm (Mars/Olympus_Mons)",
+	} {
+		if got, err := ParseReset(text, now); err == nil {
+			t.Errorf("ParseReset(%q) = %v, want an error", text, got)
+		}
+	}
+}
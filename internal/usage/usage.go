@@ -0,0 +1,179 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ribeirogab/claude-code-monitor/internal/fsutil"
+)
+
+// SchemaVersion is the version of the JSON format written by Save. Version 1
+// is the flat format with only the legacy fields, still written by
+// claude-code-usage.sh; files without a schema_version are version 1 too.
+const SchemaVersion = 2
+
+// SessionLimitID is the ID of the "Current session" limit
+const SessionLimitID = "session"
+
+// Limit is a single usage limit block from the /usage screen, such as
+// "Current session" or "Current week (all models)"
+type Limit struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Percent  int        `json:"percent"`
+	Reset    string     `json:"reset"`
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+}
+
+// UsageData holds the values captured from the Claude Code /usage screen
+type UsageData struct {
+	SchemaVersion int `json:"schema_version"`
+
+	Limits    []Limit `json:"limits"`
+	Timestamp string  `json:"timestamp"`
+
+	// CLIVersion is the claude version that printed the screen
+	CLIVersion string `json:"cli_version,omitempty"`
+	// Layout is the name of the screen layout the data was parsed as
+	Layout string `json:"layout,omitempty"`
+	// Warning is set when the screen may have been misread
+	Warning string `json:"warning,omitempty"`
+
+	// Legacy fields, kept in the JSON file for existing readers. New code
+	// should use Limits instead.
+	SessionPercent    int    `json:"session_percent"`
+	SessionReset      string `json:"session_reset"`
+	WeekAllPercent    int    `json:"week_all_percent"`
+	WeekAllReset      string `json:"week_all_reset"`
+	WeekOpusPercent   int    `json:"week_opus_percent"`
+	WeekOpusReset     string `json:"week_opus_reset"`
+	WeekSonnetPercent int    `json:"week_sonnet_percent"`
+	WeekSonnetReset   string `json:"week_sonnet_reset"`
+}
+
+var nonAlnumRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NewLimit creates a Limit from a block header like "Current week (Sonnet only)"
+func NewLimit(name string, percent int, reset string) Limit {
+	return Limit{
+		ID:      limitID(name),
+		Name:    name,
+		Percent: percent,
+		Reset:   reset,
+	}
+}
+
+// limitID turns a block header into a stable identifier, e.g.
+// "Current week (all models)" becomes "week_all_models"
+func limitID(name string) string {
+	id := strings.ToLower(strings.TrimSpace(name))
+	id = strings.TrimPrefix(id, "current ")
+	return strings.Trim(nonAlnumRe.ReplaceAllString(id, "_"), "_")
+}
+
+// Label returns a short display name, e.g. "Week (Sonnet only)"
+func (l Limit) Label() string {
+	label := strings.TrimPrefix(l.Name, "Current ")
+	if label == "" {
+		return l.ID
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// Limit returns the limit with the given ID
+func (u *UsageData) Limit(id string) (Limit, bool) {
+	for _, l := range u.Limits {
+		if l.ID == id {
+			return l, true
+		}
+	}
+	return Limit{}, false
+}
+
+// fillLegacy copies the known limits into the legacy fields
+func (u *UsageData) fillLegacy() {
+	for _, l := range u.Limits {
+		switch {
+		case l.ID == SessionLimitID:
+			u.SessionPercent, u.SessionReset = l.Percent, l.Reset
+		case l.ID == "week_all_models":
+			u.WeekAllPercent, u.WeekAllReset = l.Percent, l.Reset
+		case strings.HasPrefix(l.ID, "week_sonnet"):
+			u.WeekSonnetPercent, u.WeekSonnetReset = l.Percent, l.Reset
+		case strings.HasPrefix(l.ID, "week_opus"):
+			u.WeekOpusPercent, u.WeekOpusReset = l.Percent, l.Reset
+		}
+	}
+}
+
+// legacyLimits rebuilds Limits from the legacy fields of files written
+// before limits were stored
+func (u *UsageData) legacyLimits() []Limit {
+	limits := []Limit{
+		NewLimit("Current session", u.SessionPercent, u.SessionReset),
+		NewLimit("Current week (all models)", u.WeekAllPercent, u.WeekAllReset),
+	}
+	if u.WeekOpusReset != "" {
+		limits = append(limits, NewLimit("Current week (Opus)", u.WeekOpusPercent, u.WeekOpusReset))
+	}
+	if u.WeekSonnetReset != "" {
+		limits = append(limits, NewLimit("Current week (Sonnet only)", u.WeekSonnetPercent, u.WeekSonnetReset))
+	}
+	return limits
+}
+
+// Load reads usage data from a JSON file
+func Load(path string) (*UsageData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(data)
+}
+
+// Decode parses usage data JSON, including files written before limits were stored
+func Decode(data []byte) (*UsageData, error) {
+	var usage UsageData
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, err
+	}
+	if usage.SchemaVersion == 0 {
+		usage.SchemaVersion = 1
+	}
+	if usage.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("unsupported schema_version %d, this monitor reads up to %d", usage.SchemaVersion, SchemaVersion)
+	}
+
+	if len(usage.Limits) == 0 {
+		usage.Limits = usage.legacyLimits()
+		if t, err := time.Parse(time.RFC3339, usage.Timestamp); err == nil {
+			usage.ResolveResets(t)
+		}
+	}
+
+	return &usage, nil
+}
+
+// Save writes usage data to a JSON file atomically, so readers never see a
+// half-written file
+func Save(path string, usage *UsageData) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	usage.SchemaVersion = SchemaVersion
+	usage.fillLegacy()
+
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}